@@ -0,0 +1,120 @@
+package cabb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseActionKind(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want ActionKind
+	}{
+		{"CANASTA-1P", ActionMade1P},
+		{"CANASTA-2P", ActionMade2P},
+		{"CANASTA-3P", ActionMade3P},
+		{"TIRO1-FALLADO", ActionMissed1P},
+		{"TIRO2-FALLADO", ActionMissed2P},
+		{"TIRO3-FALLADO", ActionMissed3P},
+		{"REBOTE-OFENSIVO", ActionReboundOff},
+		{"REBOTE-DEFENSIVO", ActionReboundDef},
+		{"ASISTENCIA", ActionAssist},
+		{"RECUPERACION", ActionSteal},
+		{"PERDIDA", ActionTurnover},
+		{"TAPON", ActionBlock},
+		{"TAPON-RECIBIDO", ActionBlocked},
+		{"FALTA-PERSONAL", ActionFoulPersonal},
+		{"FALTA-RECIBIDA", ActionFoulReceived},
+		{"CAMBIO-JUGADOR-ENTRA", ActionSubIn},
+		{"CAMBIO-JUGADOR-SALE", ActionSubOut},
+		{"TIEMPO-MUERTO", ActionTimeout},
+		{"INICIO-PERIODO", ActionPeriodStart},
+		{"FIN-PERIODO", ActionPeriodEnd},
+		{"FIN-PARTIDO", ActionGameEnd},
+
+		// Normalization.
+		{" canasta-2p ", ActionMade2P},
+		{"Recuperación", ActionSteal},
+		{"tapón recibido", ActionBlocked},
+		{"TIEMPO_MUERTO", ActionTimeout},
+
+		{"", ActionUnknown},
+		{"CANASTA-4P", ActionUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := ParseActionKind(tt.raw); got != tt.want {
+			t.Errorf("ParseActionKind(%q) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestActionKindPredicates(t *testing.T) {
+	tests := []struct {
+		kind                           ActionKind
+		points                         int
+		shot, made, freeThrow, rebound bool
+		foul, substitution             bool
+	}{
+		{kind: ActionMade1P, points: 1, shot: true, made: true, freeThrow: true},
+		{kind: ActionMade3P, points: 3, shot: true, made: true},
+		{kind: ActionMissed1P, shot: true, freeThrow: true},
+		{kind: ActionMissed2P, shot: true},
+		{kind: ActionReboundOff, rebound: true},
+		{kind: ActionFoulPersonal, foul: true},
+		{kind: ActionFoulDisqualifying, foul: true},
+		{kind: ActionFoulReceived},
+		{kind: ActionSubOut, substitution: true},
+		{kind: ActionUnknown},
+	}
+
+	for _, tt := range tests {
+		k := tt.kind
+		if k.Points() != tt.points || k.IsShot() != tt.shot || k.IsMade() != tt.made ||
+			k.IsFreeThrow() != tt.freeThrow || k.IsRebound() != tt.rebound ||
+			k.IsFoul() != tt.foul || k.IsSubstitution() != tt.substitution {
+			t.Errorf("%s: points %d, shot %v, made %v, free throw %v, rebound %v, foul %v, substitution %v",
+				k, k.Points(), k.IsShot(), k.IsMade(), k.IsFreeThrow(), k.IsRebound(), k.IsFoul(), k.IsSubstitution())
+		}
+	}
+}
+
+// TestFixtureActionKinds checks every accion_tipo in the play-by-play served by
+// cmd/cabb-fake has a kind, and that the made shots add up to the final score.
+func TestFixtureActionKinds(t *testing.T) {
+	paths, err := filepath.Glob("cmd/cabb-fake/fixtures/live/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no live fixtures: %v", err)
+	}
+
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var l Live
+		if err := json.Unmarshal(b, &l); err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+
+		if u := l.UnknownActionTypes(); len(u) > 0 {
+			t.Errorf("%s: unknown action types %v", p, u)
+		}
+
+		var home, away int
+		for _, a := range l.Live.Actions {
+			switch a.TeamID {
+			case l.LiveMatch.HomeID:
+				home += a.Kind().Points()
+			case l.LiveMatch.AwayID:
+				away += a.Kind().Points()
+			}
+		}
+		if home != l.LiveMatch.HomeScore || away != l.LiveMatch.AwayScore {
+			t.Errorf("%s: made shots add up to %d - %d, want %d - %d", p, home, away, l.LiveMatch.HomeScore, l.LiveMatch.AwayScore)
+		}
+	}
+}
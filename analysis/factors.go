@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/inkel/cabb"
+)
+
+// Factors are Dean Oliver's Four Factors of one team.
+type Factors struct {
+	// EFG is the effective field goal percentage.
+	EFG float64
+	// TOV is the share of possessions ending in a turnover.
+	TOV float64
+	// ORB is the share of available offensive rebounds grabbed.
+	ORB float64
+	// FTR is the number of free throws made per field goal attempted, unlike
+	// cabb.PlayerStats.FTRate which counts the free throws attempted.
+	FTR float64
+}
+
+func (f Factors) String() string {
+	return fmt.Sprintf("eFG%% %.1f TOV%% %.1f ORB%% %.1f FTR %.3f", 100*f.EFG, 100*f.TOV, 100*f.ORB, f.FTR)
+}
+
+// FourFactors is the Four Factors report of a team and of its opponents in
+// one or more matches.
+type FourFactors struct {
+	Team     string
+	Games    int
+	Own      Factors
+	Opponent Factors
+
+	// Totals and Opponents are the box score totals the factors were
+	// computed from.
+	Totals, Opponents cabb.PlayerStats
+}
+
+// NewFactors computes the Four Factors of a team from its box score totals
+// and those of its opponents.
+func NewFactors(team, opp cabb.PlayerStats) Factors {
+	orb := float64(team.ReboundsOff)
+
+	return Factors{
+		EFG: team.EFG(),
+		TOV: team.TOVRate(),
+		ORB: cabb.Ratio(orb, orb+float64(opp.ReboundsDef)),
+		FTR: team.FTMRate(),
+	}
+}
+
+func newFourFactors(name string, games int, team, opp cabb.PlayerStats) FourFactors {
+	return FourFactors{
+		Team:      name,
+		Games:     games,
+		Own:       NewFactors(team, opp),
+		Opponent:  NewFactors(opp, team),
+		Totals:    team,
+		Opponents: opp,
+	}
+}
+
+// MatchFactors returns the Four Factors report of each side of a match.
+func MatchFactors(s cabb.Stats) (home, away FourFactors) {
+	h, a := s.HomeTotals(), s.AwayTotals()
+	return newFourFactors(s.Match.Home, 1, h, a), newFourFactors(s.Match.Away, 1, a, h)
+}
+
+// SeasonFactors aggregates the box scores of every match played by team,
+// identified by name, and returns its Four Factors report and that of its
+// opponents. Matches not involving team are ignored.
+func SeasonFactors(team string, stats []cabb.Stats) FourFactors {
+	var (
+		games     int
+		own, opps cabb.PlayerStats
+	)
+
+	for _, s := range stats {
+		var t, o cabb.PlayerStats
+
+		switch team {
+		case s.Match.Home:
+			t, o = s.HomeTotals(), s.AwayTotals()
+		case s.Match.Away:
+			t, o = s.AwayTotals(), s.HomeTotals()
+		default:
+			continue
+		}
+
+		games++
+		own, opps = own.Add(t), opps.Add(o)
+	}
+
+	return newFourFactors(team, games, own, opps)
+}
@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/inkel/cabb"
+)
+
+func TestNewFactors(t *testing.T) {
+	tests := []struct {
+		name      string
+		team, opp cabb.PlayerStats
+		want      Factors
+	}{
+		{
+			name: "box score",
+			team: cabb.PlayerStats{
+				Shots2P: 40, Made2P: 20, Shots3P: 10, Made3P: 4,
+				Shots1P: 25, Made1P: 15, Turnovers: 11, ReboundsOff: 9,
+			},
+			opp: cabb.PlayerStats{ReboundsDef: 21},
+			want: Factors{
+				EFG: 26.0 / 50,
+				TOV: 11 / (50 + 0.44*25 + 11),
+				ORB: 9.0 / 30,
+				FTR: 15.0 / 50,
+			},
+		},
+		{
+			name: "no shots",
+		},
+	}
+
+	for _, tt := range tests {
+		got := NewFactors(tt.team, tt.opp)
+
+		for _, f := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"EFG", got.EFG, tt.want.EFG},
+			{"TOV", got.TOV, tt.want.TOV},
+			{"ORB", got.ORB, tt.want.ORB},
+			{"FTR", got.FTR, tt.want.FTR},
+		} {
+			if math.Abs(f.got-f.want) > 1e-9 {
+				t.Errorf("%s: %s = %v, want %v", tt.name, f.name, f.got, f.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/inkel/cabb"
+)
+
+// Stint is a stretch of a match during which a team kept the same players on
+// court.
+type Stint struct {
+	Players []string
+	Start   cabb.GameClock
+	End     cabb.GameClock
+	For     int
+	Against int
+}
+
+func (s Stint) Duration() time.Duration { return s.End.Elapsed - s.Start.Elapsed }
+
+// LineupStats aggregates every stint of the same group of players.
+type LineupStats struct {
+	Players []string
+	Stints  int
+	Time    time.Duration
+	For     int
+	Against int
+}
+
+func (l LineupStats) PlusMinus() int { return l.For - l.Against }
+
+// PlayerOnOff is the score of a team while a player was on and off the court.
+type PlayerOnOff struct {
+	ID         string
+	Num        string
+	Time       time.Duration
+	OnFor      int
+	OnAgainst  int
+	OffFor     int
+	OffAgainst int
+}
+
+func (p PlayerOnOff) PlusMinus() int    { return p.OnFor - p.OnAgainst }
+func (p PlayerOnOff) OffPlusMinus() int { return p.OffFor - p.OffAgainst }
+
+// Rotation is the reconstruction of who was on court for a team, from the
+// substitutions in the play-by-play. Players are identified by their
+// componente_id.
+type Rotation struct {
+	TeamID   int
+	Stints   []Stint
+	Lineups  []LineupStats
+	Players  []PlayerOnOff
+	Warnings []string
+}
+
+// Lineups reconstructs the rotations of both teams of l. Starters aren't part
+// of the feed, so they're inferred as the players whose first action isn't
+// entering the court.
+func Lineups(l cabb.Live) (home, away Rotation) {
+	as := sorted(l.Live.Actions)
+	return rotation(l.LiveMatch, l.LiveMatch.HomeID, as), rotation(l.LiveMatch, l.LiveMatch.AwayID, as)
+}
+
+func rotation(m cabb.LiveMatch, teamID int, as []cabb.Action) Rotation {
+	r := Rotation{TeamID: teamID}
+
+	var (
+		nums    = make(map[string]string)
+		first   = make(map[string]cabb.ActionKind)
+		order   []string
+		onCourt = make(map[string]bool)
+	)
+
+	for _, a := range as {
+		if a.TeamID != teamID || a.ActorID == "" {
+			continue
+		}
+		if _, ok := first[a.ActorID]; !ok {
+			first[a.ActorID] = a.Kind()
+			order = append(order, a.ActorID)
+		}
+		if a.PlayerNum != "" {
+			nums[a.ActorID] = a.PlayerNum
+		}
+	}
+
+	for _, id := range order {
+		if first[id] != cabb.ActionSubIn {
+			onCourt[id] = true
+		}
+	}
+
+	if n := len(onCourt); n != 5 {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("inferred %d starters", n))
+	}
+
+	rules := cabb.FIBA.ForMatch(m)
+
+	cur := Stint{
+		Players: players(onCourt),
+		Start:   cabb.GameClock{Period: 1, Remaining: rules.Length(1)},
+	}
+
+	closeStint := func(at cabb.GameClock) {
+		cur.End = at
+		if cur.Duration() > 0 || cur.For > 0 || cur.Against > 0 {
+			r.Stints = append(r.Stints, cur)
+		}
+		cur = Stint{Players: players(onCourt), Start: at}
+	}
+
+	var last cabb.GameClock
+
+	for _, a := range as {
+		c, err := a.Clock(m)
+		if err != nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("skipping %v", err))
+			continue
+		}
+		last = c
+
+		if pts := a.Kind().Points(); pts > 0 {
+			switch a.TeamID {
+			case teamID:
+				cur.For += pts
+			case m.HomeID, m.AwayID:
+				cur.Against += pts
+			}
+			continue
+		}
+
+		if a.TeamID != teamID || !a.Kind().IsSubstitution() {
+			continue
+		}
+
+		closeStint(c)
+
+		if a.Kind() == cabb.ActionSubIn {
+			if onCourt[a.ActorID] {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s enters at %s but was already on court", a.ActorID, c))
+			}
+			onCourt[a.ActorID] = true
+		} else {
+			if !onCourt[a.ActorID] {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s leaves at %s but wasn't on court", a.ActorID, c))
+			}
+			delete(onCourt, a.ActorID)
+		}
+
+		cur.Players = players(onCourt)
+	}
+
+	if last.Period > 0 {
+		closeStint(cabb.GameClock{
+			Period:   last.Period,
+			Overtime: last.Overtime,
+			Elapsed:  last.Elapsed + last.Remaining,
+		})
+	}
+
+	r.Lineups = lineups(r.Stints)
+	r.Players = onOff(r.Stints, order, nums)
+
+	return r
+}
+
+func players(onCourt map[string]bool) []string {
+	ps := make([]string, 0, len(onCourt))
+	for id := range onCourt {
+		ps = append(ps, id)
+	}
+	sort.Strings(ps)
+	return ps
+}
+
+func lineups(ss []Stint) []LineupStats {
+	idx := make(map[string]int)
+
+	var ls []LineupStats
+
+	for _, s := range ss {
+		k := strings.Join(s.Players, ",")
+
+		i, ok := idx[k]
+		if !ok {
+			i = len(ls)
+			idx[k] = i
+			ls = append(ls, LineupStats{Players: s.Players})
+		}
+
+		ls[i].Stints++
+		ls[i].Time += s.Duration()
+		ls[i].For += s.For
+		ls[i].Against += s.Against
+	}
+
+	sort.SliceStable(ls, func(i, j int) bool { return ls[i].Time > ls[j].Time })
+
+	return ls
+}
+
+func onOff(ss []Stint, ids []string, nums map[string]string) []PlayerOnOff {
+	ps := make([]PlayerOnOff, len(ids))
+
+	for i, id := range ids {
+		p := PlayerOnOff{ID: id, Num: nums[id]}
+
+		for _, s := range ss {
+			on := false
+			for _, pid := range s.Players {
+				if pid == id {
+					on = true
+					break
+				}
+			}
+
+			if on {
+				p.Time += s.Duration()
+				p.OnFor += s.For
+				p.OnAgainst += s.Against
+			} else {
+				p.OffFor += s.For
+				p.OffAgainst += s.Against
+			}
+		}
+
+		ps[i] = p
+	}
+
+	sort.SliceStable(ps, func(i, j int) bool { return ps[i].Time > ps[j].Time })
+
+	return ps
+}
+
+// MinutesMismatch is a player whose reconstructed time on court differs from
+// the one reported by the stats endpoint.
+type MinutesMismatch struct {
+	Num           string
+	Name          string
+	Reconstructed time.Duration
+	Reported      time.Duration
+}
+
+func (m MinutesMismatch) String() string {
+	return fmt.Sprintf("#%s %s: play-by-play %v, box score %v", m.Num, strings.TrimSpace(m.Name), m.Reconstructed, m.Reported)
+}
+
+// Validate compares the time on court of each player of the rotation with the
+// box score of the team, matching players by shirt number. Differences up to
+// tolerance are ignored.
+func (r Rotation) Validate(box []cabb.PlayerStats, tolerance time.Duration) []MinutesMismatch {
+	byNum := make(map[string]time.Duration)
+	for _, p := range r.Players {
+		byNum[p.Num] += p.Time
+	}
+
+	var ms []MinutesMismatch
+
+	for _, p := range box {
+		if p.Num == "" {
+			continue
+		}
+
+		rec, rep := byNum[p.Num], p.PlayedDuration()
+
+		if d := rec - rep; d > tolerance || -d > tolerance {
+			ms = append(ms, MinutesMismatch{Num: p.Num, Name: p.Name, Reconstructed: rec, Reported: rep})
+		}
+	}
+
+	return ms
+}
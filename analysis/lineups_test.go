@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/inkel/cabb"
+)
+
+func TestLineups(t *testing.T) {
+	const home, away = 1, 2
+
+	var n int
+	action := func(team int, actor, num, typ, at string) cabb.Action {
+		n++
+		return cabb.Action{ActionNum: n, Period: 1, TeamID: team, ActorID: actor, PlayerNum: num, Type: typ, MatchTime: at}
+	}
+
+	live := func(acts ...cabb.Action) cabb.Live {
+		var l cabb.Live
+		l.LiveMatch = cabb.LiveMatch{HomeID: home, AwayID: away}
+		l.Live.Actions = acts
+		return l
+	}
+
+	starters := []cabb.Action{
+		action(home, "a", "4", "CANASTA-2P", "09:00"),
+		action(home, "b", "5", "REBOTE-DEFENSIVO", "08:30"),
+		action(home, "c", "6", "ASISTENCIA", "08:00"),
+		action(home, "d", "7", "FALTA-PERSONAL", "07:30"),
+		action(home, "e", "8", "PERDIDA", "07:00"),
+	}
+
+	tests := []struct {
+		name     string
+		live     cabb.Live
+		stints   []Stint
+		players  map[string]PlayerOnOff
+		warnings []string
+	}{
+		{
+			name: "substitution",
+			live: live(append(starters,
+				action(home, "e", "8", "CAMBIO-JUGADOR-SALE", "05:00"),
+				action(home, "f", "9", "CAMBIO-JUGADOR-ENTRA", "05:00"),
+				action(away, "x", "10", "CANASTA-3P", "03:00"),
+				action(home, "f", "9", "CANASTA-2P", "02:00"),
+			)...),
+			stints: []Stint{
+				{Players: []string{"a", "b", "c", "d", "e"}, Start: clock(10 * time.Minute), End: clock(5 * time.Minute), For: 2},
+				{Players: []string{"a", "b", "c", "d", "f"}, Start: clock(5 * time.Minute), End: clock(0), For: 2, Against: 3},
+			},
+			players: map[string]PlayerOnOff{
+				"a": {ID: "a", Num: "4", Time: 10 * time.Minute, OnFor: 4, OnAgainst: 3},
+				"e": {ID: "e", Num: "8", Time: 5 * time.Minute, OnFor: 2, OffFor: 2, OffAgainst: 3},
+				"f": {ID: "f", Num: "9", Time: 5 * time.Minute, OnFor: 2, OnAgainst: 3, OffFor: 2},
+			},
+		},
+		{
+			// A player leaving before entering started the match.
+			name: "starter without actions before leaving",
+			live: live(append(starters[:4],
+				action(home, "z", "11", "CAMBIO-JUGADOR-SALE", "05:00"),
+			)...),
+			stints: []Stint{
+				{Players: []string{"a", "b", "c", "d", "z"}, Start: clock(10 * time.Minute), End: clock(5 * time.Minute), For: 2},
+				{Players: []string{"a", "b", "c", "d"}, Start: clock(5 * time.Minute), End: clock(0)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := Lineups(tt.live)
+
+		if !reflect.DeepEqual(r.Stints, tt.stints) {
+			t.Errorf("%s: stints = %+v, want %+v", tt.name, r.Stints, tt.stints)
+		}
+
+		got := make(map[string]PlayerOnOff)
+		for _, p := range r.Players {
+			got[p.ID] = p
+		}
+		for id, want := range tt.players {
+			if got[id] != want {
+				t.Errorf("%s: player %s = %+v, want %+v", tt.name, id, got[id], want)
+			}
+		}
+
+		if !reflect.DeepEqual(r.Warnings, tt.warnings) {
+			t.Errorf("%s: warnings = %v, want %v", tt.name, r.Warnings, tt.warnings)
+		}
+	}
+}
+
+func TestLineupsWarnings(t *testing.T) {
+	var l cabb.Live
+	l.LiveMatch = cabb.LiveMatch{HomeID: 1, AwayID: 2}
+	l.Live.Actions = []cabb.Action{
+		{ActionNum: 1, Period: 1, TeamID: 1, ActorID: "a", Type: "CANASTA-2P", MatchTime: "09:00"},
+		{ActionNum: 2, Period: 1, TeamID: 1, ActorID: "b", Type: "CAMBIO-JUGADOR-ENTRA", MatchTime: "08:00"},
+		{ActionNum: 3, Period: 1, TeamID: 1, ActorID: "b", Type: "CAMBIO-JUGADOR-ENTRA", MatchTime: "07:00"},
+		{ActionNum: 4, Period: 1, TeamID: 1, ActorID: "c", Type: "CAMBIO-JUGADOR-SALE", MatchTime: "06:00"},
+		{ActionNum: 5, Period: 1, TeamID: 1, ActorID: "a", Type: "CAMBIO-JUGADOR-SALE", MatchTime: "12:00"},
+	}
+
+	home, away := Lineups(l)
+
+	want := []string{
+		"inferred 2 starters",
+		"b enters at Q1 07:00 but was already on court",
+		"skipping action 5: time remaining 12m0s longer than period 1 (10m0s)",
+	}
+	if !reflect.DeepEqual(home.Warnings, want) {
+		t.Errorf("home warnings = %q, want %q", home.Warnings, want)
+	}
+
+	if len(away.Stints) != 1 || len(away.Stints[0].Players) != 0 {
+		t.Errorf("away stints = %+v, want a single stint without players", away.Stints)
+	}
+}
+
+func clock(remaining time.Duration) cabb.GameClock {
+	return cabb.GameClock{Period: 1, Remaining: remaining, Elapsed: 10*time.Minute - remaining}
+}
+
+func TestRotationValidate(t *testing.T) {
+	r := Rotation{Players: []PlayerOnOff{
+		{ID: "a", Num: "4", Time: 20 * time.Minute},
+		{ID: "b", Num: "5", Time: 10 * time.Minute},
+	}}
+
+	box := []cabb.PlayerStats{
+		{Num: "4", Name: "PEREZ", PlayedMillis: int64(20*time.Minute/time.Millisecond) + 30000},
+		{Num: "5", Name: "GOMEZ", PlayedMillis: int64(12 * time.Minute / time.Millisecond)},
+		{Num: "", Name: "TOTALES", PlayedMillis: int64(200 * time.Minute / time.Millisecond)},
+	}
+
+	ms := r.Validate(box, time.Minute)
+	if len(ms) != 1 || ms[0].Num != "5" || ms[0].Reconstructed != 10*time.Minute || ms[0].Reported != 12*time.Minute {
+		t.Errorf("Validate() = %v, want only #5", ms)
+	}
+}
@@ -0,0 +1,213 @@
+// Package analysis derives information from the play-by-play and box scores
+// that the CABB API doesn't provide directly.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/inkel/cabb"
+)
+
+// ScorePoint is a scoring action with the running score after it.
+type ScorePoint struct {
+	Action    cabb.Action
+	Clock     cabb.GameClock
+	Home      bool
+	Points    int
+	HomeScore int
+	AwayScore int
+}
+
+// Run is a stretch of unanswered points by one team.
+type Run struct {
+	Home   bool
+	Points int
+	Start  cabb.GameClock
+	End    cabb.GameClock
+}
+
+func (r Run) String() string {
+	return fmt.Sprintf("%d-0 (%s - %s)", r.Points, r.Start, r.End)
+}
+
+// Discrepancy is a difference between the score computed from the
+// play-by-play and the one reported by the feed. Period is 0 for the final
+// score.
+type Discrepancy struct {
+	Period   int
+	Home     bool
+	Feed     int
+	Computed int
+}
+
+func (d Discrepancy) String() string {
+	team := "away"
+	if d.Home {
+		team = "home"
+	}
+
+	if d.Period == 0 {
+		return fmt.Sprintf("final %s score: feed %d, play-by-play %d", team, d.Feed, d.Computed)
+	}
+	return fmt.Sprintf("period %d %s score: feed %d, play-by-play %d", d.Period, team, d.Feed, d.Computed)
+}
+
+// Timeline is the evolution of the score of a match.
+type Timeline struct {
+	Scores []ScorePoint
+
+	LeadChanges int
+	Ties        int
+
+	HomeLargestLead int
+	AwayLargestLead int
+
+	// Runs are the runs of at least MinRun unanswered points.
+	Runs []Run
+
+	Discrepancies []Discrepancy
+
+	// ClockErrors are the scoring actions with an invalid period or
+	// tiempo_partido. They still count towards the score, with a zero Clock.
+	ClockErrors []error
+}
+
+// MinRun is the minimum of unanswered points reported as a Run.
+var MinRun = 8
+
+// NewTimeline replays the actions of l in order to compute the running
+// score, cross-checking it against the score reported by the feed.
+func NewTimeline(l cabb.Live) Timeline {
+	var (
+		t          Timeline
+		home, away int
+		leader     int
+		run        *Run
+		periods    = make(map[int][2]int)
+	)
+
+	closeRun := func() {
+		if run != nil && run.Points >= MinRun {
+			t.Runs = append(t.Runs, *run)
+		}
+		run = nil
+	}
+
+	for _, a := range sorted(l.Live.Actions) {
+		pts := a.Kind().Points()
+		if pts == 0 {
+			continue
+		}
+
+		isHome := a.TeamID == l.LiveMatch.HomeID
+		if !isHome && a.TeamID != l.LiveMatch.AwayID {
+			continue
+		}
+
+		c, err := a.Clock(l.LiveMatch)
+		if err != nil {
+			t.ClockErrors = append(t.ClockErrors, err)
+		}
+
+		ps := periods[a.Period]
+		if isHome {
+			home += pts
+			ps[0] += pts
+		} else {
+			away += pts
+			ps[1] += pts
+		}
+		periods[a.Period] = ps
+
+		t.Scores = append(t.Scores, ScorePoint{
+			Action:    a,
+			Clock:     c,
+			Home:      isHome,
+			Points:    pts,
+			HomeScore: home,
+			AwayScore: away,
+		})
+
+		switch d := home - away; {
+		case d == 0:
+			t.Ties++
+		case d > 0:
+			if leader < 0 {
+				t.LeadChanges++
+			}
+			leader = 1
+			if d > t.HomeLargestLead {
+				t.HomeLargestLead = d
+			}
+		case d < 0:
+			if leader > 0 {
+				t.LeadChanges++
+			}
+			leader = -1
+			if -d > t.AwayLargestLead {
+				t.AwayLargestLead = -d
+			}
+		}
+
+		if run == nil || run.Home != isHome {
+			closeRun()
+			run = &Run{Home: isHome, Start: c}
+		}
+		run.Points += pts
+		run.End = c
+	}
+
+	closeRun()
+
+	t.Discrepancies = check(l.LiveMatch, home, away, periods, l.Ended())
+
+	return t
+}
+
+// check compares the computed scores against the feed. While the match is
+// being played the feed may not list the current period yet, so periods
+// missing from it are only reported once the match has ended.
+func check(m cabb.LiveMatch, home, away int, periods map[int][2]int, ended bool) []Discrepancy {
+	var ds []Discrepancy
+
+	add := func(period int, isHome bool, feed, computed int) {
+		if feed != computed {
+			ds = append(ds, Discrepancy{Period: period, Home: isHome, Feed: feed, Computed: computed})
+		}
+	}
+
+	seen := make(map[int]bool)
+	for _, p := range m.Periods {
+		seen[p.Period] = true
+		c := periods[p.Period]
+		add(p.Period, true, p.HomeScore, c[0])
+		add(p.Period, false, p.AwayScore, c[1])
+	}
+
+	var missing []int
+	for p := range periods {
+		if ended && !seen[p] {
+			missing = append(missing, p)
+		}
+	}
+	sort.Ints(missing)
+
+	for _, p := range missing {
+		c := periods[p]
+		add(p, true, 0, c[0])
+		add(p, false, 0, c[1])
+	}
+
+	add(0, true, m.HomeScore, home)
+	add(0, false, m.AwayScore, away)
+
+	return ds
+}
+
+// sorted returns a copy of as sorted by ActionNum.
+func sorted(as []cabb.Action) []cabb.Action {
+	as = append([]cabb.Action(nil), as...)
+	sort.SliceStable(as, func(i, j int) bool { return as[i].ActionNum < as[j].ActionNum })
+	return as
+}
@@ -0,0 +1,193 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inkel/cabb"
+)
+
+func TestTimelineDiscrepancies(t *testing.T) {
+	const home, away = 1, 2
+
+	action := func(n, period, team int, typ string) cabb.Action {
+		return cabb.Action{ActionNum: n, Period: period, TeamID: team, Type: typ, MatchTime: "05:00"}
+	}
+
+	live := func(hs, as int, periods []cabb.Period, acts ...cabb.Action) cabb.Live {
+		var l cabb.Live
+		l.LiveMatch = cabb.LiveMatch{HomeID: home, AwayID: away, HomeScore: hs, AwayScore: as, Periods: periods}
+		l.Live.Actions = acts
+		return l
+	}
+
+	first := []cabb.Period{{Period: 1, HomeScore: 2}}
+
+	tests := []struct {
+		name string
+		live cabb.Live
+		want []string
+	}{
+		{
+			name: "consistent",
+			live: live(2, 0, first, action(1, 1, home, "CANASTA-2P")),
+		},
+		{
+			// The feed doesn't list the period being played yet.
+			name: "current period missing while playing",
+			live: live(2, 3, first, action(1, 1, home, "CANASTA-2P"), action(2, 2, away, "CANASTA-3P")),
+		},
+		{
+			name: "period missing after the end",
+			live: live(2, 3, first, action(1, 1, home, "CANASTA-2P"), action(2, 2, away, "CANASTA-3P"), action(3, 2, 0, "FIN-PARTIDO")),
+			want: []string{"period 2 away score: feed 0, play-by-play 3"},
+		},
+		{
+			name: "wrong period and final score",
+			live: live(4, 0, []cabb.Period{{Period: 1, HomeScore: 3}}, action(1, 1, home, "CANASTA-2P")),
+			want: []string{"period 1 home score: feed 3, play-by-play 2", "final home score: feed 4, play-by-play 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		ds := NewTimeline(tt.live).Discrepancies
+
+		if len(ds) != len(tt.want) {
+			t.Errorf("%s: discrepancies = %v, want %v", tt.name, ds, tt.want)
+			continue
+		}
+		for i, d := range ds {
+			if d.String() != tt.want[i] {
+				t.Errorf("%s: discrepancy %d = %q, want %q", tt.name, i, d, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	const home, away = 1, 2
+
+	// score returns a match with an action scoring each of the given points,
+	// one per minute: positive points are home ones, negative away ones.
+	score := func(pts ...int) cabb.Live {
+		typ := map[int]string{1: "CANASTA-1P", 2: "CANASTA-2P", 3: "CANASTA-3P"}
+
+		var l cabb.Live
+		l.LiveMatch = cabb.LiveMatch{HomeID: home, AwayID: away}
+		for i, p := range pts {
+			team := home
+			if p < 0 {
+				team, p = away, -p
+			}
+			// A clock running down from 10:00 in the first quarter.
+			l.Live.Actions = append(l.Live.Actions, cabb.Action{
+				ActionNum: i + 1,
+				Period:    1,
+				TeamID:    team,
+				Type:      typ[p],
+				MatchTime: fmt.Sprintf("%02d:00", 10-i),
+			})
+		}
+		return l
+	}
+
+	tests := []struct {
+		name                 string
+		live                 cabb.Live
+		minRun               int
+		leadChanges, ties    int
+		homeLead, awayLead   int
+		runs                 []string
+		homeScore, awayScore int
+	}{
+		{
+			name:   "no scoring",
+			live:   score(),
+			minRun: 8,
+		},
+		{
+			name:        "back and forth",
+			live:        score(2, -3, 1, 2, -2, -3),
+			minRun:      8,
+			leadChanges: 3, ties: 2,
+			homeLead: 2, awayLead: 3,
+			homeScore: 5, awayScore: 8,
+		},
+		{
+			name:     "runs",
+			live:     score(3, 3, 2, -2, -3, -3, 2, -2),
+			minRun:   8,
+			homeLead: 8, awayLead: 0, ties: 2,
+			runs:      []string{"8-0 (Q1 10:00 - Q1 08:00)", "8-0 (Q1 07:00 - Q1 05:00)"},
+			homeScore: 10, awayScore: 10,
+		},
+		{
+			name:     "lower MinRun",
+			live:     score(3, 3, 2, -2, -3, -3, 2, -2),
+			minRun:   2,
+			homeLead: 8, awayLead: 0, ties: 2,
+			runs: []string{"8-0 (Q1 10:00 - Q1 08:00)", "8-0 (Q1 07:00 - Q1 05:00)",
+				"2-0 (Q1 04:00 - Q1 04:00)", "2-0 (Q1 03:00 - Q1 03:00)"},
+			homeScore: 10, awayScore: 10,
+		},
+		{
+			name:      "run below MinRun",
+			live:      score(3, 2, 2, -1),
+			minRun:    8,
+			homeLead:  7,
+			homeScore: 7, awayScore: 1,
+		},
+	}
+
+	defer func(n int) { MinRun = n }(MinRun)
+
+	for _, tt := range tests {
+		MinRun = tt.minRun
+		tl := NewTimeline(tt.live)
+
+		if tl.LeadChanges != tt.leadChanges || tl.Ties != tt.ties {
+			t.Errorf("%s: %d lead changes and %d ties, want %d and %d", tt.name, tl.LeadChanges, tl.Ties, tt.leadChanges, tt.ties)
+		}
+		if tl.HomeLargestLead != tt.homeLead || tl.AwayLargestLead != tt.awayLead {
+			t.Errorf("%s: largest leads %d and %d, want %d and %d", tt.name, tl.HomeLargestLead, tl.AwayLargestLead, tt.homeLead, tt.awayLead)
+		}
+
+		var runs []string
+		for _, r := range tl.Runs {
+			runs = append(runs, r.String())
+		}
+		if !reflect.DeepEqual(runs, tt.runs) {
+			t.Errorf("%s: runs %q, want %q", tt.name, runs, tt.runs)
+		}
+
+		if n := len(tl.Scores); n > 0 {
+			last := tl.Scores[n-1]
+			if last.HomeScore != tt.homeScore || last.AwayScore != tt.awayScore {
+				t.Errorf("%s: final score %d - %d, want %d - %d", tt.name, last.HomeScore, last.AwayScore, tt.homeScore, tt.awayScore)
+			}
+		}
+		if len(tl.ClockErrors) > 0 {
+			t.Errorf("%s: clock errors %v", tt.name, tl.ClockErrors)
+		}
+	}
+}
+
+func TestTimelineClockErrors(t *testing.T) {
+	var l cabb.Live
+	l.LiveMatch = cabb.LiveMatch{HomeID: 1, AwayID: 2, HomeScore: 5}
+	l.Live.Actions = []cabb.Action{
+		{ActionNum: 1, Period: 1, TeamID: 1, Type: "CANASTA-2P", MatchTime: "09:00"},
+		{ActionNum: 2, Period: 1, TeamID: 1, Type: "CANASTA-3P", MatchTime: "??"},
+	}
+
+	tl := NewTimeline(l)
+	if len(tl.ClockErrors) != 1 || !strings.Contains(tl.ClockErrors[0].Error(), "action 2") {
+		t.Errorf("clock errors = %v, want one of action 2", tl.ClockErrors)
+	}
+	// The action still counts towards the score.
+	if len(tl.Scores) != 2 || tl.Scores[1].HomeScore != 5 || len(tl.Discrepancies) != 0 {
+		t.Errorf("scores = %+v, discrepancies = %v", tl.Scores, tl.Discrepancies)
+	}
+}
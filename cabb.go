@@ -1,48 +1,72 @@
 package cabb
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-
-	_ "github.com/mattn/go-sqlite3"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Client struct {
+	uid      string
 	deviceID string
-	key      string
-	db       *sql.DB
+
+	mu      sync.RWMutex
+	key     string
+	renewMu sync.Mutex
+
+	httpClient *http.Client
+	baseURL    string
+	userAgent  string
+	version    string
+	retry      RetryPolicy
+
+	limiter          *Limiter
+	endpointLimiters map[string]*Limiter
 }
 
-func NewClient(uid, deviceID string) (Client, error) {
-	data := url.Values{
-		"uid":              {uid},
-		"plataforma":       {"ios"},
-		"tipo_dispositivo": {"mobile"},
-		"token_push":       {},
-		"version":          {"30012"},
-		"accion":           {"acceso"},
+func NewClient(uid, deviceID string, opts ...Option) (*Client, error) {
+	return NewClientContext(context.Background(), uid, deviceID, opts...)
+}
+
+func NewClientContext(ctx context.Context, uid, deviceID string, opts ...Option) (*Client, error) {
+	c := &Client{
+		uid:        uid,
+		deviceID:   deviceID,
+		httpClient: http.DefaultClient,
+		baseURL:    defaultBaseURL,
+		version:    defaultVersion,
+		retry:      DefaultRetryPolicy,
+		limiter:    NewLimiter(DefaultRateLimit, DefaultBurst),
 	}
 
-	c := Client{
-		deviceID: deviceID,
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	if D {
-		db, err := sql.Open("sqlite3", "requests.db")
-		if err != nil {
-			return c, err
-		}
+	if err := c.connect(ctx); err != nil {
+		return c, fmt.Errorf("initiating connection: %w", err)
+	}
 
-		_, err = db.Exec(`CREATE TABLE IF NOT EXISTS requests (url TEXT, qs TEXT, body JSON)`)
-		if err != nil {
-			return c, err
-		}
+	return c, nil
+}
 
-		c.db = db
+// connect performs the handshake that returns the key used to sign every
+// other request.
+func (c *Client) connect(ctx context.Context) error {
+	data := url.Values{
+		"uid":              {c.uid},
+		"plataforma":       {"ios"},
+		"tipo_dispositivo": {"mobile"},
+		"token_push":       {},
+		"version":          {c.version},
+		"accion":           {"acceso"},
 	}
 
 	var r struct {
@@ -50,19 +74,46 @@ func NewClient(uid, deviceID string) (Client, error) {
 		Key string `json:"key"`
 	}
 
-	if err := c.request("dispositivo.ashx", data, &r); err != nil {
-		return c, fmt.Errorf("initiating connection: %w", err)
+	err := c.retry.run(ctx, "dispositivo.ashx", func() error {
+		return c.do(ctx, "dispositivo.ashx", data, "", &r)
+	})
+	if err != nil {
+		return err
 	}
 
+	c.mu.Lock()
 	c.key = r.Key
+	c.mu.Unlock()
 
-	return c, nil
+	return nil
+}
+
+func (c *Client) sessionKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.key
 }
 
-const baseURL = "https://appaficioncabb.indalweb.net/"
+// renew runs the handshake again unless another goroutine already replaced
+// the stale key.
+func (c *Client) renew(ctx context.Context, stale string) error {
+	c.renewMu.Lock()
+	defer c.renewMu.Unlock()
+
+	if c.sessionKey() != stale {
+		return nil
+	}
+
+	return c.connect(ctx)
+}
+
+const (
+	defaultBaseURL = "https://appaficioncabb.indalweb.net/"
+	defaultVersion = "30012"
+)
 
 type cabbResponse interface {
-	CABBError() error
+	generic() cabbResponseGeneric
 }
 
 type cabbResponseGeneric struct {
@@ -70,32 +121,86 @@ type cabbResponseGeneric struct {
 	Error  string `json:"error"`
 }
 
-func (r cabbResponseGeneric) CABBError() error {
-	if r.Result != "error" {
-		return nil
+func (r cabbResponseGeneric) generic() cabbResponseGeneric { return r }
+
+// request performs an idempotent request signed with the current key,
+// retrying transient failures according to the client's RetryPolicy.
+func (c *Client) request(ctx context.Context, path string, data url.Values, d cabbResponse) error {
+	return c.send(ctx, path, data, d, true)
+}
+
+// requestOnce performs a request that changes state on the server, so it's
+// never retried on transient failures, as it could have been applied anyway.
+func (c *Client) requestOnce(ctx context.Context, path string, data url.Values, d cabbResponse) error {
+	return c.send(ctx, path, data, d, false)
+}
+
+// send performs a request signed with the current key. If the server reports
+// the key as expired, a new one is requested and idempotent requests are sent
+// again once. Other requests return the error: expired sessions are detected
+// from the error message, so the request could have been applied anyway.
+func (c *Client) send(ctx context.Context, path string, data url.Values, d cabbResponse, idempotent bool) error {
+	key := c.sessionKey()
+
+	err := c.attempt(ctx, path, data, key, d, idempotent)
+
+	var expired *SessionExpiredError
+	if !errors.As(err, &expired) {
+		return err
+	}
+
+	if err := c.renew(ctx, key); err != nil {
+		return fmt.Errorf("renewing session: %w", err)
 	}
-	return fmt.Errorf("error response: %s", r.Error)
+
+	if !idempotent {
+		return err
+	}
+
+	return c.attempt(ctx, path, data, c.sessionKey(), d, idempotent)
 }
 
-var D bool
+func (c *Client) attempt(ctx context.Context, path string, data url.Values, key string, d cabbResponse, idempotent bool) error {
+	if !idempotent {
+		return c.do(ctx, path, data, key, d)
+	}
+
+	return c.retry.run(ctx, path, func() error {
+		return c.do(ctx, path, data, key, d)
+	})
+}
 
-func (c Client) request(path string, data url.Values, d cabbResponse) error {
-	if data == nil {
-		data = url.Values{}
+func (c *Client) do(ctx context.Context, path string, params url.Values, key string, d cabbResponse) error {
+	data := url.Values{}
+	for k, v := range params {
+		data[k] = v
 	}
 
-	url, err := url.JoinPath(baseURL, path)
+	url, err := url.JoinPath(c.baseURL, path)
 	if err != nil {
 		return fmt.Errorf("building URL for path %s: %w", path, err)
 	}
 
 	data.Set("id_dispositivo", c.deviceID)
 
-	if c.key != "" {
-		data.Set("key", c.key)
+	if key != "" {
+		data.Set("key", key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("building request for path %s: %w", path, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
 	}
 
-	res, err := http.PostForm(url, data)
+	if err := c.wait(ctx, path); err != nil {
+		return err
+	}
+
+	res, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -106,16 +211,22 @@ func (c Client) request(path string, data url.Values, d cabbResponse) error {
 		return fmt.Errorf("reading response body: %w", err)
 	}
 
-	if D {
-		c.db.Exec(`INSERT INTO requests (url, qs, body) VALUES (?, ?, ?)`, url, data.Encode(), body)
+	if res.StatusCode != http.StatusOK {
+		return &HTTPStatusError{
+			Endpoint:   path,
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Body:       snippet(body),
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+		}
 	}
 
 	if err := json.Unmarshal(body, &d); err != nil {
-		return err
+		return &DecodeError{Endpoint: path, Body: snippet(body), Err: err}
 	}
 
-	if err := d.CABBError(); err != nil {
-		return fmt.Errorf("error: %s", err)
+	if r := d.generic(); r.Result == "error" {
+		return newAPIError(path, data.Get("accion"), r)
 	}
 
 	return nil
@@ -128,19 +239,79 @@ type Team struct {
 	Name           string `json:"nombre"`
 }
 
-func (c Client) Teams() ([]Team, error) {
+func (c *Client) Teams() ([]Team, error) {
+	return c.TeamsContext(context.Background())
+}
+
+func (c *Client) TeamsContext(ctx context.Context) ([]Team, error) {
 	var r struct {
 		cabbResponseGeneric
 		Teams []Team `json:"misequipos"`
 	}
 
-	if err := c.request("misequiposV2.ashx", url.Values{"accion": {"listado"}}, &r); err != nil {
+	if err := c.request(ctx, "misequiposV2.ashx", url.Values{"accion": {"listado"}}, &r); err != nil {
 		return nil, fmt.Errorf("fetching teams: %w", err)
 	}
 
 	return r.Teams, nil
 }
 
+// Actions of misequiposV2.ashx changing the followed teams. Like the ones
+// walking the federation, these names haven't been checked against traffic
+// recorded from the official app yet.
+const (
+	actionFollow   = "alta"
+	actionUnfollow = "baja"
+)
+
+// Follow adds t to the followed teams, returning it with its NotificationID.
+func (c *Client) Follow(t Team) (Team, error) {
+	return c.FollowContext(context.Background(), t)
+}
+
+func (c *Client) FollowContext(ctx context.Context, t Team) (Team, error) {
+	var r struct {
+		cabbResponseGeneric
+		NotificationID string `json:"idEquipoNotificacion"`
+	}
+
+	data := url.Values{
+		"accion":    {actionFollow},
+		"id_equipo": {t.ID},
+	}
+
+	if err := c.requestOnce(ctx, "misequiposV2.ashx", data, &r); err != nil {
+		return t, fmt.Errorf("following team %s: %w", t.Name, err)
+	}
+
+	if r.NotificationID != "" {
+		t.NotificationID = r.NotificationID
+	}
+
+	return t, nil
+}
+
+// Unfollow removes t from the followed teams.
+func (c *Client) Unfollow(t Team) error {
+	return c.UnfollowContext(context.Background(), t)
+}
+
+func (c *Client) UnfollowContext(ctx context.Context, t Team) error {
+	var r cabbResponseGeneric
+
+	data := url.Values{
+		"accion":                 {actionUnfollow},
+		"id_equipo":              {t.ID},
+		"id_equipo_notificacion": {t.NotificationID},
+	}
+
+	if err := c.requestOnce(ctx, "misequiposV2.ashx", data, &r); err != nil {
+		return fmt.Errorf("unfollowing team %s: %w", t.Name, err)
+	}
+
+	return nil
+}
+
 type GameDay struct {
 	Name    string  `json:"jornada"`
 	Date    string  `json:"fecha"`
@@ -157,6 +328,11 @@ type Match struct {
 	Date      string `json:"fecha" db:"date"`
 	Time      string `json:"hora" db:"time"`
 	Status    string `json:"estado" db:"status"`
+
+	HomePoints Score       `json:"-" db:"-"`
+	AwayPoints Score       `json:"-" db:"-"`
+	Kickoff    time.Time   `json:"-" db:"-"`
+	State      MatchStatus `json:"-" db:"-"`
 }
 
 func (m Match) Title() string {
@@ -182,7 +358,11 @@ type Season struct {
 	Positions []Position `json:"clasificacion"`
 }
 
-func (c Client) Season(teamID string) (Season, error) {
+func (c *Client) Season(teamID string) (Season, error) {
+	return c.SeasonContext(context.Background(), teamID)
+}
+
+func (c *Client) SeasonContext(ctx context.Context, teamID string) (Season, error) {
 	s := Season{TeamID: teamID}
 
 	data := url.Values{
@@ -190,7 +370,7 @@ func (c Client) Season(teamID string) (Season, error) {
 		"id_equipo": {teamID},
 	}
 
-	if err := c.request("misequiposV2.ashx", data, &s); err != nil {
+	if err := c.request(ctx, "misequiposV2.ashx", data, &s); err != nil {
 		return s, err
 	}
 
@@ -244,10 +424,14 @@ type PlayerStats struct {
 	Played       string `json:"tiempo_jugado" db:"played"`
 }
 
-func (c Client) Stats(m Match) (Stats, error) {
+func (c *Client) Stats(m Match) (Stats, error) {
+	return c.StatsContext(context.Background(), m)
+}
+
+func (c *Client) StatsContext(ctx context.Context, m Match) (Stats, error) {
 	var s = Stats{MatchID: m.MatchID}
 
-	if err := c.request("envivo/estadisticas.ashx", url.Values{"id_partido": {m.MatchID}}, &s); err != nil {
+	if err := c.request(ctx, "envivo/estadisticas.ashx", url.Values{"id_partido": {m.MatchID}}, &s); err != nil {
 		return s, err
 	}
 
@@ -292,15 +476,17 @@ type Live struct {
 	} `json:"envivo"`
 }
 
-func (c Client) Live(m Match) (Live, error) {
+func (c *Client) Live(m Match) (Live, error) {
+	return c.LiveContext(context.Background(), m)
+}
+
+func (c *Client) LiveContext(ctx context.Context, m Match) (Live, error) {
 	var l Live
 
-	//D = true
-	if err := c.request("envivo/partido.ashx", url.Values{"id_partido": {m.MatchID}}, &l); err != nil {
-		return l, nil
+	if err := c.request(ctx, "envivo/partido.ashx", url.Values{"id_partido": {m.MatchID}}, &l); err != nil {
+		return l, err
 	}
 	l.Match = m
-	//D = false
 
 	return l, nil
 }
@@ -338,3 +524,94 @@ type Teams struct {
 	ID   string `json:"id"`
 	Name string `json:"nombre"`
 }
+
+// Team returns t as a Team of the given club, suitable for Client.Season.
+func (t Teams) Team(c Club) Team {
+	return Team{ID: t.ID, Name: t.Name, Club: c.Name}
+}
+
+// Actions of misequiposV2.ashx walking the federation. The response types above
+// were declared along the first version of the client, but these names were
+// inferred from their fields and haven't been checked against traffic recorded
+// from the official app yet, so the server could reject them with an APIError.
+const (
+	actionDelegations = "delegaciones"
+	actionTournaments = "competiciones"
+	actionClubs       = "clubes"
+	actionClubTeams   = "equipos"
+)
+
+func (c *Client) Delegations() ([]Delegation, error) {
+	return c.DelegationsContext(context.Background())
+}
+
+func (c *Client) DelegationsContext(ctx context.Context) ([]Delegation, error) {
+	var r Delegations
+
+	if err := c.request(ctx, "misequiposV2.ashx", url.Values{"accion": {actionDelegations}}, &r); err != nil {
+		return nil, fmt.Errorf("fetching delegations: %w", err)
+	}
+
+	return r.Delegations, nil
+}
+
+func (c *Client) Tournaments(d Delegation) ([]Tournament, error) {
+	return c.TournamentsContext(context.Background(), d)
+}
+
+func (c *Client) TournamentsContext(ctx context.Context, d Delegation) ([]Tournament, error) {
+	var r Tournaments
+
+	data := url.Values{
+		"accion":     {actionTournaments},
+		"delegacion": {d.Name},
+	}
+
+	if err := c.request(ctx, "misequiposV2.ashx", data, &r); err != nil {
+		return nil, fmt.Errorf("fetching tournaments for %s: %w", d.Name, err)
+	}
+
+	return r.Tournaments, nil
+}
+
+func (c *Client) Clubs(t Tournament) ([]Club, error) {
+	return c.ClubsContext(context.Background(), t)
+}
+
+func (c *Client) ClubsContext(ctx context.Context, t Tournament) ([]Club, error) {
+	var r Clubs
+
+	data := url.Values{
+		"accion":         {actionClubs},
+		"id_competicion": {t.ID},
+	}
+
+	if err := c.request(ctx, "misequiposV2.ashx", data, &r); err != nil {
+		return nil, fmt.Errorf("fetching clubs for %s: %w", t.Name, err)
+	}
+
+	return r.Clubs, nil
+}
+
+func (c *Client) ClubTeams(t Tournament, cl Club) ([]Teams, error) {
+	return c.ClubTeamsContext(context.Background(), t, cl)
+}
+
+func (c *Client) ClubTeamsContext(ctx context.Context, t Tournament, cl Club) ([]Teams, error) {
+	var r struct {
+		cabbResponseGeneric
+		Teams []Teams `json:"valores"`
+	}
+
+	data := url.Values{
+		"accion":         {actionClubTeams},
+		"id_competicion": {t.ID},
+		"id_club":        {cl.ID},
+	}
+
+	if err := c.request(ctx, "misequiposV2.ashx", data, &r); err != nil {
+		return nil, fmt.Errorf("fetching teams of %s in %s: %w", cl.Name, t.Name, err)
+	}
+
+	return r.Teams, nil
+}
@@ -0,0 +1,514 @@
+package cabb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI is a CABB API server answering each handshake with a new key, k1,
+// k2 and so on, and every other request with the result of handle. It records
+// the requests it gets.
+type fakeAPI struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	requests   []fakeRequest
+	handshakes int
+}
+
+type fakeRequest struct {
+	Path      string
+	Form      url.Values
+	UserAgent string
+}
+
+func newFakeAPI(t *testing.T, handle func(r *http.Request) any) *fakeAPI {
+	t.Helper()
+
+	api := &fakeAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		api.mu.Lock()
+		api.requests = append(api.requests, fakeRequest{r.URL.Path, r.PostForm, r.UserAgent()})
+		if r.URL.Path == "/dispositivo.ashx" {
+			api.handshakes++
+		}
+		key := fmt.Sprintf("k%d", api.handshakes)
+		api.mu.Unlock()
+
+		var v any = map[string]string{"resultado": "correcto", "key": key}
+		if r.URL.Path != "/dispositivo.ashx" {
+			v = handle(r)
+		}
+
+		if code, ok := v.(status); ok {
+			http.Error(w, http.StatusText(int(code)), int(code))
+			return
+		}
+
+		json.NewEncoder(w).Encode(v)
+	}))
+	t.Cleanup(api.Close)
+
+	return api
+}
+
+// status is returned by handlers of a fakeAPI to answer with an HTTP error.
+type status int
+
+// Handshakes returns how many keys were handed out.
+func (api *fakeAPI) Handshakes() int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.handshakes
+}
+
+// Requests returns the requests received so far.
+func (api *fakeAPI) Requests() []fakeRequest {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return append([]fakeRequest(nil), api.requests...)
+}
+
+func teamsResponse(*http.Request) any {
+	return map[string]any{
+		"resultado":  "correcto",
+		"misequipos": []Team{{ID: "DEF17", Name: "DEFENSORES U17", NotificationID: "9001"}},
+	}
+}
+
+// countingTransport counts the requests made through it.
+type countingTransport struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (ct *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ct.mu.Lock()
+	ct.n++
+	ct.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestOptions(t *testing.T) {
+	api := newFakeAPI(t, teamsResponse)
+
+	var ct countingTransport
+
+	c, err := NewClient("u1", "d1",
+		WithBaseURL(api.URL),
+		WithHTTPClient(&http.Client{Transport: &ct}),
+		WithUserAgent("cabb-test/1.0"),
+		WithAppVersion("31000"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := c.Teams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 1 || ts[0].ID != "DEF17" {
+		t.Errorf("Teams() = %+v", ts)
+	}
+
+	rs := api.Requests()
+	if len(rs) != 2 || ct.n != 2 {
+		t.Fatalf("got %d requests, %d through the HTTP client, want 2", len(rs), ct.n)
+	}
+
+	for _, r := range rs {
+		if r.UserAgent != "cabb-test/1.0" {
+			t.Errorf("%s: User-Agent = %q", r.Path, r.UserAgent)
+		}
+		if r.Form.Get("id_dispositivo") != "d1" {
+			t.Errorf("%s: id_dispositivo = %q", r.Path, r.Form.Get("id_dispositivo"))
+		}
+	}
+
+	if h := rs[0]; h.Path != "/dispositivo.ashx" || h.Form.Get("version") != "31000" || h.Form.Get("uid") != "u1" {
+		t.Errorf("handshake = %+v", h)
+	}
+	if r := rs[1]; r.Path != "/misequiposV2.ashx" || r.Form.Get("accion") != "listado" || r.Form.Get("key") != "k1" {
+		t.Errorf("request = %+v", r)
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	api := newFakeAPI(t, teamsResponse)
+
+	// A nil HTTP client keeps the default one.
+	if _, err := NewClient("u1", "d1", WithBaseURL(api.URL), WithHTTPClient(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	h := api.Requests()[0]
+	if h.Form.Get("version") != defaultVersion {
+		t.Errorf("version = %q, want %q", h.Form.Get("version"), defaultVersion)
+	}
+	if h.UserAgent != "Go-http-client/1.1" {
+		t.Errorf("User-Agent = %q, want Go's default", h.UserAgent)
+	}
+}
+
+func TestContextCanceled(t *testing.T) {
+	started := make(chan struct{}, 1)
+
+	api := newFakeAPI(t, func(r *http.Request) any {
+		started <- struct{}{}
+		<-r.Context().Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := NewClientContext(ctx, "u1", "d1", WithBaseURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan error)
+	go func() {
+		_, err := c.TeamsContext(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("TeamsContext() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TeamsContext() didn't return after canceling the context")
+	}
+
+	// A canceled context doesn't reach the server.
+	n := len(api.Requests())
+	if _, err := NewClientContext(ctx, "u1", "d1", WithBaseURL(api.URL)); !errors.Is(err, context.Canceled) {
+		t.Errorf("NewClientContext() error = %v, want %v", err, context.Canceled)
+	}
+	if len(api.Requests()) != n {
+		t.Error("request sent with a canceled context")
+	}
+}
+
+// expired answers requests signed with the first key as an expired session.
+func expired(next func(*http.Request) any) func(*http.Request) any {
+	return func(r *http.Request) any {
+		if r.PostForm.Get("key") == "k1" {
+			return map[string]string{"resultado": "error", "error": "key no válida"}
+		}
+		return next(r)
+	}
+}
+
+func TestRenewExpiredKey(t *testing.T) {
+	api := newFakeAPI(t, expired(teamsResponse))
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := c.Teams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 1 {
+		t.Errorf("Teams() = %+v", ts)
+	}
+
+	var got []string
+	for _, r := range api.Requests() {
+		got = append(got, r.Path+" "+r.Form.Get("key"))
+	}
+	want := []string{"/dispositivo.ashx ", "/misequiposV2.ashx k1", "/dispositivo.ashx ", "/misequiposV2.ashx k2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+
+	// The new key is used from then on.
+	if _, err := c.Teams(); err != nil {
+		t.Fatal(err)
+	}
+	if n := api.Handshakes(); n != 2 {
+		t.Errorf("%d handshakes, want 2", n)
+	}
+}
+
+func TestRenewOnlyOnce(t *testing.T) {
+	api := newFakeAPI(t, func(*http.Request) any {
+		return map[string]string{"resultado": "error", "error": "key no válida"}
+	})
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Teams()
+
+	var se *SessionExpiredError
+	if !errors.As(err, &se) {
+		t.Fatalf("Teams() error = %v, want a SessionExpiredError", err)
+	}
+	if n, rs := api.Handshakes(), len(api.Requests()); n != 2 || rs != 4 {
+		t.Errorf("%d handshakes and %d requests, want 2 and 4", n, rs)
+	}
+}
+
+func TestRenewConcurrently(t *testing.T) {
+	const callers = 8
+
+	// Every caller sends the first key before any of them gets a reply, so
+	// all of them see it expire.
+	var arrived sync.WaitGroup
+	arrived.Add(callers)
+
+	api := newFakeAPI(t, func(r *http.Request) any {
+		if r.PostForm.Get("key") == "k1" {
+			arrived.Done()
+			arrived.Wait()
+		}
+		return expired(teamsResponse)(r)
+	})
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := c.Teams()
+			errs <- err
+		}()
+	}
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	if n := api.Handshakes(); n != 2 {
+		t.Errorf("%d handshakes, want a single renewal shared by every caller", n)
+	}
+}
+
+func TestRequestOnce(t *testing.T) {
+	tests := []struct {
+		name       string
+		handle     func(*http.Request) any
+		handshakes int
+	}{
+		{"transient failure", func(*http.Request) any { return status(http.StatusServiceUnavailable) }, 1},
+		// The key is renewed for the next requests.
+		{"expired session", expired(teamsResponse), 2},
+	}
+
+	for _, tt := range tests {
+		api := newFakeAPI(t, tt.handle)
+
+		c, err := NewClient("u1", "d1", WithBaseURL(api.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var r cabbResponseGeneric
+		if err := c.requestOnce(context.Background(), "misequiposV2.ashx", url.Values{"accion": {"alta"}}, &r); err == nil {
+			t.Errorf("%s: requestOnce() succeeded", tt.name)
+		}
+
+		var sent int
+		for _, r := range api.Requests() {
+			if r.Form.Get("accion") == "alta" {
+				sent++
+			}
+		}
+		if sent != 1 {
+			t.Errorf("%s: request sent %d times, want once", tt.name, sent)
+		}
+		if n := api.Handshakes(); n != tt.handshakes {
+			t.Errorf("%s: %d handshakes, want %d", tt.name, n, tt.handshakes)
+		}
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	responses := map[string]string{
+		"delegaciones":  `{"resultado":"correcto","delegaciones":[{"provincia":"BUENOS AIRES"},{"provincia":"CORDOBA"}]}`,
+		"competiciones": `{"resultado":"correcto","valores":[{"id":"U17-2023","nombre":"U17 METROPOLITANO"}]}`,
+		"clubes":        `{"resultado":"correcto","valores":[{"id":"CL-DEF","nombre":"Club Defensores"}]}`,
+		"equipos":       `{"resultado":"correcto","valores":[{"id":"DEF17","nombre":"DEFENSORES U17"}]}`,
+	}
+
+	api := newFakeAPI(t, func(r *http.Request) any {
+		b, ok := responses[r.PostForm.Get("accion")]
+		if !ok {
+			return map[string]string{"resultado": "error", "error": "acción desconocida"}
+		}
+		return json.RawMessage(b)
+	})
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := c.Delegations()
+	if err != nil || !reflect.DeepEqual(ds, []Delegation{{Name: "BUENOS AIRES"}, {Name: "CORDOBA"}}) {
+		t.Fatalf("Delegations() = %+v, %v", ds, err)
+	}
+
+	ts, err := c.Tournaments(ds[0])
+	if err != nil || !reflect.DeepEqual(ts, []Tournament{{ID: "U17-2023", Name: "U17 METROPOLITANO"}}) {
+		t.Fatalf("Tournaments() = %+v, %v", ts, err)
+	}
+
+	cs, err := c.Clubs(ts[0])
+	if err != nil || !reflect.DeepEqual(cs, []Club{{ID: "CL-DEF", Name: "Club Defensores"}}) {
+		t.Fatalf("Clubs() = %+v, %v", cs, err)
+	}
+
+	tms, err := c.ClubTeams(ts[0], cs[0])
+	if err != nil || !reflect.DeepEqual(tms, []Teams{{ID: "DEF17", Name: "DEFENSORES U17"}}) {
+		t.Fatalf("ClubTeams() = %+v, %v", tms, err)
+	}
+	if tm := tms[0].Team(cs[0]); tm != (Team{ID: "DEF17", Name: "DEFENSORES U17", Club: "Club Defensores"}) {
+		t.Errorf("Team() = %+v", tm)
+	}
+
+	want := []url.Values{
+		{"accion": {"delegaciones"}},
+		{"accion": {"competiciones"}, "delegacion": {"BUENOS AIRES"}},
+		{"accion": {"clubes"}, "id_competicion": {"U17-2023"}},
+		{"accion": {"equipos"}, "id_competicion": {"U17-2023"}, "id_club": {"CL-DEF"}},
+	}
+	for i, r := range api.Requests()[1:] {
+		for k := range want[i] {
+			if r.Form.Get(k) != want[i].Get(k) {
+				t.Errorf("request %d: %s = %q, want %q", i, k, r.Form.Get(k), want[i].Get(k))
+			}
+		}
+	}
+}
+
+func TestBrowseRejected(t *testing.T) {
+	api := newFakeAPI(t, func(*http.Request) any {
+		return map[string]string{"resultado": "error", "error": "acción desconocida"}
+	})
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var apiErr *APIError
+	if _, err := c.Delegations(); !errors.As(err, &apiErr) || apiErr.Action != "delegaciones" {
+		t.Errorf("Delegations() error = %v, want an APIError of the action", err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	api := newFakeAPI(t, func(r *http.Request) any {
+		switch r.PostForm.Get("accion") {
+		case "alta":
+			return map[string]string{"resultado": "correcto", "idEquipoNotificacion": "9002"}
+		case "baja":
+			return map[string]string{"resultado": "correcto"}
+		}
+		return map[string]string{"resultado": "error", "error": "acción desconocida"}
+	})
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm, err := c.Follow(Team{ID: "DEF17", Name: "DEFENSORES U17"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm.NotificationID != "9002" {
+		t.Errorf("Follow() NotificationID = %q, want 9002", tm.NotificationID)
+	}
+
+	if err := c.Unfollow(tm); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []url.Values{
+		{"accion": {"alta"}, "id_equipo": {"DEF17"}},
+		{"accion": {"baja"}, "id_equipo": {"DEF17"}, "id_equipo_notificacion": {"9002"}},
+	}
+	for i, r := range api.Requests()[1:] {
+		for k := range want[i] {
+			if r.Form.Get(k) != want[i].Get(k) {
+				t.Errorf("request %d: %s = %q, want %q", i, k, r.Form.Get(k), want[i].Get(k))
+			}
+		}
+	}
+}
+
+func TestFollowNeverRetried(t *testing.T) {
+	calls := []struct {
+		action string
+		fn     func(*Client) error
+	}{
+		{"alta", func(c *Client) error { _, err := c.Follow(Team{ID: "DEF17"}); return err }},
+		{"baja", func(c *Client) error { return c.Unfollow(Team{ID: "DEF17", NotificationID: "9001"}) }},
+	}
+	failures := []struct {
+		name   string
+		handle func(*http.Request) any
+	}{
+		{"transient failure", func(*http.Request) any { return status(http.StatusServiceUnavailable) }},
+		{"expired session", expired(func(*http.Request) any { return map[string]string{"resultado": "correcto"} })},
+	}
+
+	for _, call := range calls {
+		for _, f := range failures {
+			api := newFakeAPI(t, f.handle)
+
+			c, err := NewClient("u1", "d1", WithBaseURL(api.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := call.fn(c); err == nil {
+				t.Errorf("%s, %s: succeeded", call.action, f.name)
+			}
+
+			var sent int
+			for _, r := range api.Requests() {
+				if r.Form.Get("accion") == call.action {
+					sent++
+				}
+			}
+			if sent != 1 {
+				t.Errorf("%s, %s: sent %d times, want once", call.action, f.name, sent)
+			}
+		}
+	}
+}
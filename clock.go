@@ -0,0 +1,166 @@
+package cabb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClockRules are the lengths of the periods of a match.
+type ClockRules struct {
+	Periods        int
+	PeriodLength   time.Duration
+	OvertimeLength time.Duration
+}
+
+// FIBA are the default clock rules: four 10 minutes quarters and 5 minutes
+// overtimes.
+var FIBA = ClockRules{
+	Periods:        4,
+	PeriodLength:   10 * time.Minute,
+	OvertimeLength: 5 * time.Minute,
+}
+
+// ForMatch returns r adjusted to the number of periods of m.
+func (r ClockRules) ForMatch(m LiveMatch) ClockRules {
+	if m.NumPeriods > 0 {
+		r.Periods = m.NumPeriods
+	}
+	return r
+}
+
+// Length returns the length of the given period, starting at 1.
+func (r ClockRules) Length(period int) time.Duration {
+	if period > r.Periods {
+		return r.OvertimeLength
+	}
+	return r.PeriodLength
+}
+
+// start returns the time elapsed in the match when period starts.
+func (r ClockRules) start(period int) time.Duration {
+	var d time.Duration
+	for p := 1; p < period; p++ {
+		d += r.Length(p)
+	}
+	return d
+}
+
+// GameClock is the moment of a match an action happened. Overtime is the
+// number of the overtime period, or 0 during regulation.
+type GameClock struct {
+	Period    int
+	Overtime  int
+	Remaining time.Duration
+	Elapsed   time.Duration
+}
+
+// Clock returns the GameClock for the given period and tiempo_partido, which
+// is the time remaining in the period.
+func (r ClockRules) Clock(period int, matchTime string) (GameClock, error) {
+	if period < 1 {
+		return GameClock{}, fmt.Errorf("invalid period %d", period)
+	}
+
+	rem, err := ParseMatchTime(matchTime)
+	if err != nil {
+		return GameClock{}, err
+	}
+
+	l := r.Length(period)
+	if rem > l {
+		return GameClock{}, fmt.Errorf("time remaining %v longer than period %d (%v)", rem, period, l)
+	}
+
+	var ot int
+	if period > r.Periods {
+		ot = period - r.Periods
+	}
+
+	return GameClock{
+		Period:    period,
+		Overtime:  ot,
+		Remaining: rem,
+		Elapsed:   r.start(period) + l - rem,
+	}, nil
+}
+
+// Clock returns when a happened in m, using the FIBA clock rules.
+func (a Action) Clock(m LiveMatch) (GameClock, error) {
+	c, err := FIBA.ForMatch(m).Clock(a.Period, a.MatchTime)
+	if err != nil {
+		return c, fmt.Errorf("action %d: %w", a.ActionNum, err)
+	}
+	return c, nil
+}
+
+func (c GameClock) Before(o GameClock) bool { return c.Elapsed < o.Elapsed }
+
+// LastOf reports whether c is within the last d of the given period, e.g.
+// LastOf(4, 2*time.Minute).
+func (c GameClock) LastOf(period int, d time.Duration) bool {
+	return c.Period == period && c.Remaining <= d
+}
+
+func (c GameClock) String() string {
+	m, s := int(c.Remaining/time.Minute), int(c.Remaining%time.Minute/time.Second)
+	if c.Overtime > 0 {
+		return fmt.Sprintf("OT%d %02d:%02d", c.Overtime, m, s)
+	}
+	return fmt.Sprintf("Q%d %02d:%02d", c.Period, m, s)
+}
+
+// ParseMatchTime parses clock values like "09:41", "9:41", "00:09:41" or
+// "41.5".
+func ParseMatchTime(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty match time")
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid match time %q", s)
+	}
+
+	var secs float64
+
+	for i, p := range parts {
+		if i < len(parts)-1 {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 || (i > 0 && n >= 60) {
+				return 0, fmt.Errorf("invalid match time %q", s)
+			}
+			secs = (secs + float64(n)) * 60
+			continue
+		}
+
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 || (len(parts) > 1 && f >= 60) {
+			return 0, fmt.Errorf("invalid match time %q", s)
+		}
+		secs += f
+	}
+
+	if secs*float64(time.Second) > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid match time %q", s)
+	}
+
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// PlayedDuration returns the time the player was on court.
+func (p PlayerStats) PlayedDuration() time.Duration {
+	if p.PlayedMillis > 0 {
+		return time.Duration(p.PlayedMillis) * time.Millisecond
+	}
+
+	d, err := ParseMatchTime(p.Played)
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
@@ -0,0 +1,140 @@
+package cabb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMatchTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{"09:41", 9*time.Minute + 41*time.Second, false},
+		{"9:41", 9*time.Minute + 41*time.Second, false},
+		{" 00:09:41 ", 9*time.Minute + 41*time.Second, false},
+		{"41.5", 41*time.Second + 500*time.Millisecond, false},
+		{"0:00", 0, false},
+		{"", 0, true},
+		{"9:60", 0, true},
+		{"-1", 0, true},
+		{"1:2:3:4", 0, true},
+		{"abc", 0, true},
+		{"NaN", 0, true},
+		{"nan", 0, true},
+		{"Inf", 0, true},
+		{"+Inf", 0, true},
+		{"-Inf", 0, true},
+		{"9:NaN", 0, true},
+		{"1e300", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMatchTime(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseMatchTime(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMatchTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	tests := []struct {
+		period    int
+		matchTime string
+		want      GameClock
+		err       bool
+	}{
+		{period: 1, matchTime: "10:00", want: GameClock{Period: 1, Remaining: 10 * time.Minute}},
+		{period: 1, matchTime: "05:30", want: GameClock{Period: 1, Remaining: 5*time.Minute + 30*time.Second, Elapsed: 4*time.Minute + 30*time.Second}},
+		{period: 1, matchTime: "00:00", want: GameClock{Period: 1, Elapsed: 10 * time.Minute}},
+		{period: 2, matchTime: "10:00", want: GameClock{Period: 2, Remaining: 10 * time.Minute, Elapsed: 10 * time.Minute}},
+		{period: 2, matchTime: "00:01", want: GameClock{Period: 2, Remaining: time.Second, Elapsed: 19*time.Minute + 59*time.Second}},
+		{period: 3, matchTime: "10:00", want: GameClock{Period: 3, Remaining: 10 * time.Minute, Elapsed: 20 * time.Minute}},
+		{period: 4, matchTime: "02:00", want: GameClock{Period: 4, Remaining: 2 * time.Minute, Elapsed: 38 * time.Minute}},
+		{period: 4, matchTime: "00:00", want: GameClock{Period: 4, Elapsed: 40 * time.Minute}},
+		{period: 5, matchTime: "05:00", want: GameClock{Period: 5, Overtime: 1, Remaining: 5 * time.Minute, Elapsed: 40 * time.Minute}},
+		{period: 5, matchTime: "00:00", want: GameClock{Period: 5, Overtime: 1, Elapsed: 45 * time.Minute}},
+		{period: 6, matchTime: "05:00", want: GameClock{Period: 6, Overtime: 2, Remaining: 5 * time.Minute, Elapsed: 45 * time.Minute}},
+		{period: 6, matchTime: "01:15", want: GameClock{Period: 6, Overtime: 2, Remaining: time.Minute + 15*time.Second, Elapsed: 48*time.Minute + 45*time.Second}},
+
+		{period: 0, matchTime: "10:00", err: true},
+		{period: 4, matchTime: "10:01", err: true},
+		// Overtimes are shorter than quarters.
+		{period: 5, matchTime: "06:00", err: true},
+		{period: 2, matchTime: "", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := FIBA.Clock(tt.period, tt.matchTime)
+		if (err != nil) != tt.err {
+			t.Errorf("Clock(%d, %q) error = %v, want error %v", tt.period, tt.matchTime, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Clock(%d, %q) = %+v, want %+v", tt.period, tt.matchTime, got, tt.want)
+		}
+	}
+}
+
+func TestActionClock(t *testing.T) {
+	tests := []struct {
+		name    string
+		periods int
+		action  Action
+		want    GameClock
+	}{
+		{"FIBA", 0, Action{Period: 5, MatchTime: "03:00"}, GameClock{Period: 5, Overtime: 1, Remaining: 3 * time.Minute, Elapsed: 42 * time.Minute}},
+		{"four quarters", 4, Action{Period: 4, MatchTime: "00:00"}, GameClock{Period: 4, Elapsed: 40 * time.Minute}},
+		// Halves: the third period is the first overtime.
+		{"two halves", 2, Action{Period: 3, MatchTime: "02:00"}, GameClock{Period: 3, Overtime: 1, Remaining: 2 * time.Minute, Elapsed: 23 * time.Minute}},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.action.Clock(LiveMatch{NumPeriods: tt.periods})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Clock() = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if s := got.String(); tt.want.Overtime > 0 && s[:2] != "OT" {
+			t.Errorf("%s: String() = %q, want an overtime", tt.name, s)
+		}
+	}
+
+	if _, err := (Action{ActionNum: 7, Period: 1, MatchTime: "11:00"}).Clock(LiveMatch{}); err == nil || !strings.Contains(err.Error(), "action 7") {
+		t.Errorf("Clock() error = %v, want one naming the action", err)
+	}
+}
+
+func TestGameClockString(t *testing.T) {
+	tests := []struct {
+		c    GameClock
+		want string
+	}{
+		{GameClock{Period: 1, Remaining: 10 * time.Minute}, "Q1 10:00"},
+		{GameClock{Period: 4, Remaining: 59 * time.Second}, "Q4 00:59"},
+		{GameClock{Period: 6, Overtime: 2, Remaining: time.Minute + 15*time.Second}, "OT2 01:15"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+
+	c := GameClock{Period: 4, Remaining: 90 * time.Second, Elapsed: 38*time.Minute + 30*time.Second}
+	if !c.LastOf(4, 2*time.Minute) || c.LastOf(4, time.Minute) || c.LastOf(3, 2*time.Minute) {
+		t.Errorf("LastOf() of %s", c)
+	}
+	if !c.Before(GameClock{Elapsed: 39 * time.Minute}) || c.Before(GameClock{Elapsed: 38 * time.Minute}) {
+		t.Errorf("Before() of %s", c)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/inkel/cabb"
+)
+
+// fixtures holds the responses served by the fake, loaded from a directory
+// with the following layout:
+//
+//	teams.json            list of followed teams
+//	federation.json       delegations, with their tournaments, clubs and teams
+//	seasons/<teamID>.json detalleEquipo response for the team
+//	stats/<matchID>.json  estadisticas response for the match
+//	live/<matchID>.json   partido response for the match, with every action
+type fixtures struct {
+	teams      []cabb.Team
+	federation []delegation
+	seasons    map[string]json.RawMessage
+	stats      map[string]json.RawMessage
+	live       map[string]cabb.Live
+}
+
+func loadFixtures(dir string) (*fixtures, error) {
+	fx := &fixtures{
+		seasons: make(map[string]json.RawMessage),
+		stats:   make(map[string]json.RawMessage),
+		live:    make(map[string]cabb.Live),
+	}
+
+	if err := readJSON(filepath.Join(dir, "teams.json"), &fx.teams); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := readJSON(filepath.Join(dir, "federation.json"), &fx.federation); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := readDir(filepath.Join(dir, "seasons"), fx.seasons); err != nil {
+		return nil, err
+	}
+
+	if err := readDir(filepath.Join(dir, "stats"), fx.stats); err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]json.RawMessage)
+	if err := readDir(filepath.Join(dir, "live"), raw); err != nil {
+		return nil, err
+	}
+
+	for id, b := range raw {
+		var l cabb.Live
+		if err := json.Unmarshal(b, &l); err != nil {
+			return nil, fmt.Errorf("decoding live fixture %s: %w", id, err)
+		}
+
+		as := l.Live.Actions
+		sort.SliceStable(as, func(i, j int) bool { return as[i].ActionNum < as[j].ActionNum })
+
+		fx.live[id] = l
+	}
+
+	return fx, nil
+}
+
+type delegation struct {
+	cabb.Delegation
+	Tournaments []tournament `json:"competiciones"`
+}
+
+type tournament struct {
+	cabb.Tournament
+	Clubs []club `json:"clubes"`
+}
+
+type club struct {
+	cabb.Club
+	Teams []cabb.Teams `json:"equipos"`
+}
+
+func (fx *fixtures) tournament(id string) (tournament, bool) {
+	for _, d := range fx.federation {
+		for _, t := range d.Tournaments {
+			if t.ID == id {
+				return t, true
+			}
+		}
+	}
+	return tournament{}, false
+}
+
+// team looks up a team by ID in the federation.
+func (fx *fixtures) team(id string) (cabb.Team, bool) {
+	for _, d := range fx.federation {
+		for _, t := range d.Tournaments {
+			for _, c := range t.Clubs {
+				for _, tt := range c.Teams {
+					if tt.ID == id {
+						return tt.Team(c.Club), true
+					}
+				}
+			}
+		}
+	}
+	return cabb.Team{}, false
+}
+
+func readJSON(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readDir loads every JSON file in dir into m, keyed by file name without
+// extension. A missing directory is not an error.
+func readDir(dir string, m map[string]json.RawMessage) error {
+	es, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range es {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if !json.Valid(b) {
+			return fmt.Errorf("invalid JSON in %s", path)
+		}
+
+		m[strings.TrimSuffix(e.Name(), ".json")] = b
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"time"
+
+	"github.com/inkel/cabb"
+)
+
+type liveResponse struct {
+	Result string         `json:"resultado"`
+	Match  cabb.LiveMatch `json:"partido"`
+	Live   struct {
+		Actions []cabb.Action `json:"historialacciones"`
+	} `json:"envivo"`
+}
+
+// liveAt returns the state of the match at the given time, revealing one
+// action every step since the server started. A zero step serves the whole
+// match.
+func (s *server) liveAt(l cabb.Live, now time.Time) liveResponse {
+	as := l.Live.Actions
+
+	n := len(as)
+	if s.step > 0 {
+		n = 1 + int(now.Sub(s.start)/s.step)
+		if n > len(as) {
+			n = len(as)
+		}
+	}
+
+	res := liveResponse{Result: "correcto", Match: l.LiveMatch}
+	res.Live.Actions = as[:n]
+
+	if n < len(as) {
+		res.Match.HomeScore, res.Match.AwayScore, res.Match.Periods = score(l.LiveMatch, as[:n])
+	}
+
+	return res
+}
+
+// score computes the running score of the match after the given actions.
+func score(m cabb.LiveMatch, as []cabb.Action) (int, int, []cabb.Period) {
+	var (
+		home, away int
+		ps         []cabb.Period
+	)
+
+	for _, a := range as {
+		if len(ps) == 0 || ps[len(ps)-1].Period != a.Period {
+			ps = append(ps, cabb.Period{Period: a.Period})
+		}
+
+		pts := a.Kind().Points()
+		if pts == 0 {
+			continue
+		}
+
+		p := &ps[len(ps)-1]
+		if a.TeamID == m.HomeID {
+			home += pts
+			p.HomeScore += pts
+		} else {
+			away += pts
+			p.AwayScore += pts
+		}
+	}
+
+	return home, away, ps
+}
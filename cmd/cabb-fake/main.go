@@ -0,0 +1,268 @@
+// Command cabb-fake is a fake CABB API server, serving fixtures from a
+// directory, useful to test the TUI and the stats tool end to end.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/inkel/cabb"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", "localhost:8080", "Address to listen on")
+		dir      = flag.String("fixtures", "fixtures", "Directory with the fixtures to serve")
+		step     = flag.Duration("live-step", 5*time.Second, "Time between live actions; 0 serves whole matches")
+		keyTTL   = flag.Duration("key-ttl", 0, "Time until session keys expire; 0 never expires them")
+		failRate = flag.Float64("fail-rate", 0, "Fraction of requests answered with 503 Service Unavailable")
+	)
+	flag.Parse()
+
+	fx, err := loadFixtures(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := newServer(fx)
+	s.step = *step
+	s.keyTTL = *keyTTL
+	s.failRate = *failRate
+
+	log.Printf("serving %d teams, %d seasons, %d stats and %d live matches on http://%s/",
+		len(fx.teams), len(fx.seasons), len(fx.stats), len(fx.live), *addr)
+
+	log.Fatal(http.ListenAndServe(*addr, s.routes()))
+}
+
+type server struct {
+	fx       *fixtures
+	start    time.Time
+	step     time.Duration
+	keyTTL   time.Duration
+	failRate float64
+
+	mu       sync.Mutex
+	keys     map[string]time.Time
+	n        int
+	followed []cabb.Team
+}
+
+// newServer returns a server of the fixtures that serves whole live matches
+// and never expires keys.
+func newServer(fx *fixtures) *server {
+	return &server{
+		fx:       fx,
+		start:    time.Now(),
+		keys:     make(map[string]time.Time),
+		followed: fx.teams,
+	}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/dispositivo.ashx", s.device)
+	mux.Handle("/misequiposV2.ashx", s.authed(s.teams))
+	mux.Handle("/envivo/estadisticas.ashx", s.authed(s.stats))
+	mux.Handle("/envivo/partido.ashx", s.authed(s.live))
+
+	return s.logged(mux)
+}
+
+func (s *server) logged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("%s %s %s", r.Method, r.URL.Path, r.PostForm.Get("accion"))
+
+		if s.failRate > 0 && rand.Float64() < s.failRate {
+			http.Error(w, "simulated failure", http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authed rejects requests without a valid key with an error mentioning it,
+// which clients take as an expired session.
+func (s *server) authed(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		issued, ok := s.keys[r.PostForm.Get("key")]
+		s.mu.Unlock()
+
+		if !ok || (s.keyTTL > 0 && time.Since(issued) > s.keyTTL) {
+			fail(w, "key no válida")
+			return
+		}
+
+		h(w, r)
+	})
+}
+
+func (s *server) device(w http.ResponseWriter, r *http.Request) {
+	if r.PostForm.Get("accion") != "acceso" {
+		fail(w, "acción desconocida")
+		return
+	}
+
+	s.mu.Lock()
+	s.n++
+	key := fmt.Sprintf("fake-%d", s.n)
+	s.keys[key] = time.Now()
+	s.mu.Unlock()
+
+	reply(w, map[string]string{"resultado": "correcto", "key": key})
+}
+
+func (s *server) teams(w http.ResponseWriter, r *http.Request) {
+	switch r.PostForm.Get("accion") {
+	case "listado":
+		s.mu.Lock()
+		ts := append([]cabb.Team(nil), s.followed...)
+		s.mu.Unlock()
+		reply(w, map[string]any{"resultado": "correcto", "misequipos": ts})
+
+	case "alta":
+		t, ok := s.fx.team(r.PostForm.Get("id_equipo"))
+		if !ok {
+			fail(w, "equipo no encontrado")
+			return
+		}
+		reply(w, map[string]string{"resultado": "correcto", "idEquipoNotificacion": s.follow(t)})
+
+	case "baja":
+		s.unfollow(r.PostForm.Get("id_equipo"))
+		reply(w, map[string]string{"resultado": "correcto"})
+
+	case "detalleEquipo":
+		b, ok := s.fx.seasons[r.PostForm.Get("id_equipo")]
+		if !ok {
+			fail(w, "equipo no encontrado")
+			return
+		}
+		raw(w, b)
+
+	case "delegaciones":
+		ds := make([]cabb.Delegation, len(s.fx.federation))
+		for i, d := range s.fx.federation {
+			ds[i] = d.Delegation
+		}
+		reply(w, map[string]any{"resultado": "correcto", "delegaciones": ds})
+
+	case "competiciones":
+		var ts []cabb.Tournament
+		for _, d := range s.fx.federation {
+			if d.Name == r.PostForm.Get("delegacion") {
+				for _, t := range d.Tournaments {
+					ts = append(ts, t.Tournament)
+				}
+			}
+		}
+		reply(w, map[string]any{"resultado": "correcto", "valores": ts})
+
+	case "clubes":
+		t, ok := s.fx.tournament(r.PostForm.Get("id_competicion"))
+		if !ok {
+			fail(w, "competición no encontrada")
+			return
+		}
+		cs := make([]cabb.Club, len(t.Clubs))
+		for i, c := range t.Clubs {
+			cs[i] = c.Club
+		}
+		reply(w, map[string]any{"resultado": "correcto", "valores": cs})
+
+	case "equipos":
+		t, ok := s.fx.tournament(r.PostForm.Get("id_competicion"))
+		if !ok {
+			fail(w, "competición no encontrada")
+			return
+		}
+		var ts []cabb.Teams
+		for _, c := range t.Clubs {
+			if c.ID == r.PostForm.Get("id_club") {
+				ts = c.Teams
+			}
+		}
+		reply(w, map[string]any{"resultado": "correcto", "valores": ts})
+
+	default:
+		fail(w, "acción desconocida")
+	}
+}
+
+func (s *server) follow(t cabb.Team) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range s.followed {
+		if f.ID == t.ID {
+			return f.NotificationID
+		}
+	}
+
+	s.n++
+	t.NotificationID = fmt.Sprintf("%d", 9000+s.n)
+	s.followed = append(s.followed, t)
+
+	return t.NotificationID
+}
+
+func (s *server) unfollow(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := s.followed[:0:0]
+	for _, t := range s.followed {
+		if t.ID != id {
+			ts = append(ts, t)
+		}
+	}
+	s.followed = ts
+}
+
+func (s *server) stats(w http.ResponseWriter, r *http.Request) {
+	b, ok := s.fx.stats[r.PostForm.Get("id_partido")]
+	if !ok {
+		fail(w, "no hay estadísticas para el partido")
+		return
+	}
+	raw(w, b)
+}
+
+func (s *server) live(w http.ResponseWriter, r *http.Request) {
+	l, ok := s.fx.live[r.PostForm.Get("id_partido")]
+	if !ok {
+		fail(w, "partido no encontrado")
+		return
+	}
+	reply(w, s.liveAt(l, time.Now()))
+}
+
+func reply(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("encoding response: %v", err)
+	}
+}
+
+func raw(w http.ResponseWriter, b []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func fail(w http.ResponseWriter, msg string) {
+	reply(w, map[string]string{"resultado": "error", "error": msg})
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/inkel/cabb"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func fake(t *testing.T) (*server, *httptest.Server) {
+	t.Helper()
+
+	fx, err := loadFixtures("fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newServer(fx)
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+
+	return s, srv
+}
+
+func client(t *testing.T, srv *httptest.Server) *cabb.Client {
+	t.Helper()
+
+	c, err := cabb.NewClient("u", "d",
+		cabb.WithBaseURL(srv.URL),
+		cabb.WithRetryPolicy(cabb.RetryPolicy{}),
+		cabb.WithRateLimit(0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// post sends a request to the fake and returns the error message it answers
+// with, or the key of a successful handshake.
+func post(t *testing.T, srv *httptest.Server, path string, form url.Values) string {
+	t.Helper()
+
+	res, err := http.PostForm(srv.URL+path, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var r struct {
+		Result string `json:"resultado"`
+		Error  string `json:"error"`
+		Key    string `json:"key"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Result == "error" && r.Error == "" {
+		t.Fatalf("%s: error without a message", path)
+	}
+	if r.Result == "correcto" {
+		return r.Key
+	}
+	return r.Error
+}
+
+func TestAuth(t *testing.T) {
+	s, srv := fake(t)
+	s.keyTTL = 50 * time.Millisecond
+
+	listado := func(key string) url.Values { return url.Values{"accion": {"listado"}, "key": {key}} }
+
+	if msg := post(t, srv, "/misequiposV2.ashx", url.Values{"accion": {"listado"}}); msg != "key no válida" {
+		t.Errorf("without a key: %q", msg)
+	}
+	if msg := post(t, srv, "/misequiposV2.ashx", listado("unknown")); msg != "key no válida" {
+		t.Errorf("unknown key: %q", msg)
+	}
+
+	key := post(t, srv, "/dispositivo.ashx", url.Values{"accion": {"acceso"}})
+	if key == "" {
+		t.Fatal("no key")
+	}
+	if msg := post(t, srv, "/misequiposV2.ashx", listado(key)); msg != "" {
+		t.Errorf("valid key: %q", msg)
+	}
+
+	time.Sleep(2 * s.keyTTL)
+	if msg := post(t, srv, "/misequiposV2.ashx", listado(key)); msg != "key no válida" {
+		t.Errorf("expired key: %q", msg)
+	}
+
+	if msg := post(t, srv, "/dispositivo.ashx", url.Values{"accion": {"salir"}}); msg != "acción desconocida" {
+		t.Errorf("unknown handshake action: %q", msg)
+	}
+}
+
+func TestClientRenewsExpiredKeys(t *testing.T) {
+	s, srv := fake(t)
+	s.keyTTL = 50 * time.Millisecond
+
+	c := client(t, srv)
+	time.Sleep(2 * s.keyTTL)
+
+	if _, err := c.Season("DEF17"); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n != 2 {
+		t.Errorf("%d keys handed out, want 2", s.n)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	_, srv := fake(t)
+	c := client(t, srv)
+
+	ts, err := c.Teams()
+	if err != nil || len(ts) != 1 || ts[0].ID != "DEF17" {
+		t.Errorf("Teams() = %+v, %v", ts, err)
+	}
+
+	s, err := c.Season("DEF17")
+	if err != nil || len(s.Season) == 0 || len(s.Positions) != 4 {
+		t.Fatalf("Season() = %+v, %v", s, err)
+	}
+	m := s.Season[0].Matches[0]
+	if m.MatchID != "M01" {
+		t.Fatalf("first match = %+v", m)
+	}
+
+	st, err := c.Stats(m)
+	if err != nil || st.Match.Home != "DEFENSORES U17" || len(st.Stats.Home) == 0 || len(st.Stats.Away) == 0 {
+		t.Errorf("Stats() = %+v, %v", st, err)
+	}
+
+	l, err := c.Live(m)
+	if err != nil || l.LiveMatch.HomeScore != 64 || l.LiveMatch.AwayScore != 60 || len(l.Live.Actions) == 0 {
+		t.Errorf("Live() = %+v, %v", l.LiveMatch, err)
+	}
+
+	// Missing fixtures are API errors.
+	missing := []struct {
+		name string
+		fn   func() error
+	}{
+		{"season", func() error { _, err := c.Season("NOPE"); return err }},
+		{"stats", func() error { _, err := c.Stats(cabb.Match{MatchID: "M02"}); return err }},
+		{"live", func() error { _, err := c.Live(cabb.Match{MatchID: "M02"}); return err }},
+	}
+	for _, tt := range missing {
+		var apiErr *cabb.APIError
+		if err := tt.fn(); !errors.As(err, &apiErr) {
+			t.Errorf("%s: error = %v, want an APIError", tt.name, err)
+		}
+	}
+}
+
+func TestLiveAt(t *testing.T) {
+	fx, err := loadFixtures("fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := fx.live["M01"]
+	n := len(l.Live.Actions)
+
+	s := newServer(fx)
+	s.step = time.Second
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		actions int
+	}{
+		{"start", 0, 1},
+		{"between steps", 2500 * time.Millisecond, 3},
+		{"end", time.Hour, n},
+	}
+
+	for _, tt := range tests {
+		res := s.liveAt(l, s.start.Add(tt.elapsed))
+		if len(res.Live.Actions) != tt.actions {
+			t.Errorf("%s: %d actions, want %d", tt.name, len(res.Live.Actions), tt.actions)
+		}
+
+		home, away, _ := score(l.LiveMatch, res.Live.Actions)
+		if res.Match.HomeScore != home || res.Match.AwayScore != away {
+			t.Errorf("%s: score %d - %d, want %d - %d", tt.name, res.Match.HomeScore, res.Match.AwayScore, home, away)
+		}
+	}
+
+	// The actions of the fixture add up to its final score.
+	home, away, ps := score(l.LiveMatch, l.Live.Actions)
+	if home != l.LiveMatch.HomeScore || away != l.LiveMatch.AwayScore || !reflect.DeepEqual(ps, l.LiveMatch.Periods) {
+		t.Errorf("fixture actions add up to %d - %d %v, want %d - %d %v",
+			home, away, ps, l.LiveMatch.HomeScore, l.LiveMatch.AwayScore, l.LiveMatch.Periods)
+	}
+
+	s.step = 0
+	if res := s.liveAt(l, s.start); len(res.Live.Actions) != n || !reflect.DeepEqual(res.Match, l.LiveMatch) {
+		t.Errorf("whole match: %d actions, %+v", len(res.Live.Actions), res.Match)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	_, srv := fake(t)
+	c := client(t, srv)
+
+	tm, err := c.Follow(cabb.Team{ID: "DEF17"})
+	if err != nil || tm.NotificationID == "" {
+		t.Fatalf("following an already followed team: %+v, %v", tm, err)
+	}
+
+	if err := c.Unfollow(tm); err != nil {
+		t.Fatal(err)
+	}
+	if ts, err := c.Teams(); err != nil || len(ts) != 0 {
+		t.Errorf("Teams() after Unfollow = %+v, %v", ts, err)
+	}
+
+	if _, err := c.Follow(cabb.Team{ID: "NOPE"}); err == nil {
+		t.Error("following an unknown team succeeded")
+	}
+}
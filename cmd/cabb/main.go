@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/inkel/cabb"
 	"github.com/inkel/cabb/cmd/cabb/messages"
+	"github.com/inkel/cabb/cmd/cabb/pages/browse"
 	"github.com/inkel/cabb/cmd/cabb/pages/live"
 	"github.com/inkel/cabb/cmd/cabb/pages/season"
 	"github.com/inkel/cabb/cmd/cabb/pages/stats"
 	"github.com/inkel/cabb/cmd/cabb/pages/teams"
+	"github.com/inkel/cabb/ratings"
+	"github.com/inkel/cabb/recorder"
 )
 
 func dieIf(err error) {
@@ -22,19 +29,35 @@ func dieIf(err error) {
 }
 
 func main() {
-	cabb.D = true
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			dieIf(migrateCmd(os.Args[2:]))
+			return
+		case "sync":
+			dieIf(syncCmd(os.Args[2:]))
+			return
+		case "players":
+			dieIf(playersCmd(os.Args[2:]))
+			return
+		}
+	}
 
-	var (
-		uid      = os.Getenv("CABBUID")
-		deviceID = os.Getenv("DEVICEID")
-	)
+	c, closeClient, err := newClient()
+	dieIf(err)
+	defer closeClient()
 
-	c, err := cabb.NewClient(uid, deviceID)
+	rpath, err := ratings.DefaultPath()
+	dieIf(err)
+
+	r, err := ratings.Load(rpath)
 	dieIf(err)
 
 	m := model{
-		client:  c,
-		spinner: spinner.New(spinner.WithSpinner(spinner.Points)),
+		client:      c,
+		spinner:     spinner.New(spinner.WithSpinner(spinner.Points)),
+		ratings:     r,
+		ratingsPath: rpath,
 	}
 
 	p := tea.NewProgram(m)
@@ -44,6 +67,58 @@ func main() {
 	}
 }
 
+// newClient returns a client configured from the environment: CABBUID and
+// DEVICEID for the credentials, CABBURL to use another server, and
+// CABBREPLAY to replay recorded requests instead of recording them to
+// requests.db. The returned function releases the recorder.
+func newClient() (*cabb.Client, func() error, error) {
+	var (
+		uid      = os.Getenv("CABBUID")
+		deviceID = os.Getenv("DEVICEID")
+		done     = func() error { return nil }
+	)
+
+	hc := &http.Client{Timeout: 30 * time.Second}
+
+	if path := os.Getenv("CABBREPLAY"); path != "" {
+		cs, err := openCassette(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		hc.Transport = cs
+	} else {
+		rec, err := recorder.OpenSQLite("requests.db")
+		if err != nil {
+			return nil, nil, err
+		}
+		done = rec.Close
+		hc.Transport = recorder.New(rec, nil)
+	}
+
+	opts := []cabb.Option{cabb.WithHTTPClient(hc)}
+	if u := os.Getenv("CABBURL"); u != "" {
+		opts = append(opts, cabb.WithBaseURL(u))
+	}
+
+	c, err := cabb.NewClient(uid, deviceID, opts...)
+	if err != nil {
+		done()
+		return nil, nil, err
+	}
+
+	return c, done, nil
+}
+
+// openCassette loads the recorded requests to replay from either a JSONL file
+// or a sqlite database written by the recorder.
+func openCassette(path string) (*recorder.Cassette, error) {
+	if filepath.Ext(path) != ".db" {
+		return recorder.OpenCassette(path)
+	}
+
+	return recorder.OpenSQLiteCassette(path)
+}
+
 type page int
 
 const (
@@ -52,13 +127,14 @@ const (
 	pageSeason
 	pageStats
 	pageLive
+	pageBrowse
 )
 
 type model struct {
 	page   page
 	msg    string
 	err    error
-	client cabb.Client
+	client *cabb.Client
 	w, h   int
 
 	spinner spinner.Model
@@ -66,6 +142,13 @@ type model struct {
 	season  season.Model
 	stats   stats.Model
 	live    live.Model
+	browse  browse.Model
+
+	events    <-chan cabb.LiveEvent
+	stopWatch context.CancelFunc
+
+	ratings     *ratings.Ratings
+	ratingsPath string
 }
 
 func (m model) Init() tea.Cmd {
@@ -90,6 +173,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if msg.Type == tea.KeyCtrlC {
+			m.season = m.season.Stop()
 			return m, tea.Quit
 		}
 
@@ -114,8 +198,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case cabb.Season:
+		m.season.Stop()
+		if m.ratings.AddSeason(msg) > 0 {
+			if err := m.ratings.Save(m.ratingsPath); err != nil {
+				m.err = err
+			}
+		}
 		m.page = pageSeason
-		m.season = season.NewModel(m.w, m.h, msg)
+		m.season = season.NewModel(m.w, m.h, msg, m.ratings)
 
 	case cabb.Stats:
 		m.page = pageStats
@@ -124,13 +214,46 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case cabb.Live:
 		m.page = pageLive
 		m.live = live.New(m.w, m.h, msg)
+		m = m.watch(msg)
+		return m, waitLive(m.events)
+
+	case cabb.LiveEvent:
+		m.live, cmd = m.live.Update(msg)
+		return m, tea.Batch(cmd, waitLive(m.events))
 
 	case messages.BackMsg:
 		m.page = pageSeason
+		m = m.unwatch()
 
 	case messages.LiveMatchMsg:
 		return m, m.liveMatch(msg.Match)
 
+	case messages.TeamsMsg:
+		m.page = pageTeams
+		return m, nil
+
+	case messages.BrowseMsg:
+		m.browse = browse.New(m.w, m.h)
+		return m, tea.Batch(messages.Loading("Cargando delegaciones"), m.loadDelegations)
+
+	case cabb.Delegation:
+		return m, tea.Batch(messages.Loading("Cargando torneos de %s", msg.Name), m.loadTournaments(msg))
+
+	case cabb.Tournament:
+		return m, tea.Batch(messages.Loading("Cargando clubes de %s", msg.Name), m.loadClubs(msg))
+
+	case messages.ClubMsg:
+		return m, tea.Batch(messages.Loading("Cargando equipos de %s", msg.Club.Name), m.loadClubTeams(msg))
+
+	case []cabb.Delegation, []cabb.Tournament, []cabb.Club, []cabb.Teams:
+		m.page = pageBrowse
+
+	case messages.FollowMsg:
+		return m, tea.Batch(messages.Loading("Siguiendo a %s", msg.Team.Name), m.follow(msg.Team))
+
+	case messages.UnfollowMsg:
+		return m, tea.Batch(messages.Loading("Dejando de seguir a %s", msg.Team.Name), m.unfollow(msg.Team))
+
 	case error:
 		m.err = msg
 	}
@@ -144,6 +267,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case pageStats:
 		m.stats, cmd = m.stats.Update(msg)
+
+	case pageLive:
+		m.live, cmd = m.live.Update(msg)
+
+	case pageBrowse:
+		m.browse, cmd = m.browse.Update(msg)
 	}
 
 	return m, cmd
@@ -169,6 +298,9 @@ func (m model) View() string {
 
 	case pageLive:
 		return m.live.View()
+
+	case pageBrowse:
+		return m.browse.View()
 	}
 
 	return fmt.Sprintf("NO PAGE FOUND FOR %v\n", m.page)
@@ -183,6 +315,62 @@ func (m model) loadTeams() tea.Msg {
 	return ts
 }
 
+func (m model) loadDelegations() tea.Msg {
+	ds, err := m.client.Delegations()
+	if err != nil {
+		return err
+	}
+	return ds
+}
+
+func (m model) loadTournaments(d cabb.Delegation) tea.Cmd {
+	return func() tea.Msg {
+		ts, err := m.client.Tournaments(d)
+		if err != nil {
+			return err
+		}
+		return ts
+	}
+}
+
+func (m model) loadClubs(t cabb.Tournament) tea.Cmd {
+	return func() tea.Msg {
+		cs, err := m.client.Clubs(t)
+		if err != nil {
+			return err
+		}
+		return cs
+	}
+}
+
+func (m model) loadClubTeams(c messages.ClubMsg) tea.Cmd {
+	return func() tea.Msg {
+		ts, err := m.client.ClubTeams(c.Tournament, c.Club)
+		if err != nil {
+			return err
+		}
+		return ts
+	}
+}
+
+func (m model) follow(team cabb.Team) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.client.Follow(team); err != nil {
+			return err
+		}
+		return m.loadTeams()
+	}
+}
+
+func (m model) unfollow(team cabb.Team) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.client.Unfollow(team); err != nil {
+			return err
+		}
+		return m.loadTeams()
+	}
+}
+
 func (m model) loadSeason(team cabb.Team) tea.Cmd {
 	return func() tea.Msg {
 		s, err := m.client.Season(team.ID)
@@ -213,3 +401,37 @@ func (m model) liveMatch(match cabb.Match) tea.Cmd {
 		return l
 	}
 }
+
+// watch starts following the match of l, replacing any other match being
+// watched.
+func (m model) watch(l cabb.Live) model {
+	m = m.unwatch()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopWatch = cancel
+	m.events = m.client.Watch(ctx, l.Match, cabb.WatchSince(l))
+
+	return m
+}
+
+func (m model) unwatch() model {
+	if m.stopWatch != nil {
+		m.stopWatch()
+	}
+	m.stopWatch, m.events = nil, nil
+	return m
+}
+
+func waitLive(events <-chan cabb.LiveEvent) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+
+	return func() tea.Msg {
+		e, ok := <-events
+		if !ok {
+			return nil
+		}
+		return e
+	}
+}
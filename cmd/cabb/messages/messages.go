@@ -28,3 +28,32 @@ type LiveMatchMsg struct {
 func LiveMatch(match cabb.Match) tea.Cmd {
 	return func() tea.Msg { return LiveMatchMsg{match} }
 }
+
+type TeamsMsg struct{}
+
+var Teams tea.Cmd = Load(TeamsMsg{})
+
+type BrowseMsg struct{}
+
+var Browse tea.Cmd = Load(BrowseMsg{})
+
+type ClubMsg struct {
+	Tournament cabb.Tournament
+	Club       cabb.Club
+}
+
+type FollowMsg struct {
+	Team cabb.Team
+}
+
+func Follow(team cabb.Team) tea.Cmd {
+	return func() tea.Msg { return FollowMsg{team} }
+}
+
+type UnfollowMsg struct {
+	Team cabb.Team
+}
+
+func Unfollow(team cabb.Team) tea.Cmd {
+	return func() tea.Msg { return UnfollowMsg{team} }
+}
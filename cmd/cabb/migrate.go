@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/inkel/cabb/migrate"
+	"github.com/inkel/cabb/recorder"
+	"github.com/inkel/cabb/store"
+)
+
+// database is a sqlite database of one of the tools, and its migrations.
+type database struct {
+	name       string
+	path       string
+	migrations func() ([]migrate.Migration, error)
+	up         func(*sql.DB) ([]migrate.Migration, error)
+}
+
+func migrateCmd(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	storePath := fs.String("store", "cabb.db", "path of the store database")
+	requestsPath := fs.String("requests", "requests.db", "path of the recorded requests database")
+	create := fs.Bool("create", false, "create the databases that don't exist when running up")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: cabb migrate [flags] status|up\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	dbs := []database{
+		{"store", *storePath, store.Migrations, store.Migrate},
+		{"requests", *requestsPath, recorder.Migrations, recorder.Migrate},
+	}
+
+	var run func(database, *sql.DB) error
+
+	switch fs.Arg(0) {
+	case "status":
+		run = migrationStatus
+	case "up":
+		run = migrationUp
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	for _, d := range dbs {
+		dsn := d.path
+
+		if _, err := os.Stat(d.path); err != nil {
+			switch {
+			case fs.Arg(0) == "status":
+				fmt.Printf("%s (%s): %v\n", d.name, d.path, err)
+				continue
+			case !errors.Is(err, os.ErrNotExist):
+				return fmt.Errorf("%s (%s): %w", d.name, d.path, err)
+			case !*create:
+				return fmt.Errorf("%s (%s) doesn't exist, use -create to create it", d.name, d.path)
+			}
+		}
+
+		if fs.Arg(0) == "status" {
+			// status never writes to the database.
+			dsn = "file:" + d.path + "?mode=ro"
+		}
+
+		db, err := sql.Open("sqlite3", dsn)
+		if err != nil {
+			return err
+		}
+
+		err = run(d, db)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("%s (%s): %w", d.name, d.path, err)
+		}
+	}
+
+	return nil
+}
+
+func migrationStatus(d database, db *sql.DB) error {
+	ms, err := d.migrations()
+	if err != nil {
+		return err
+	}
+
+	s, err := migrate.GetStatus(db, ms)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (%s): versión %d\n", d.name, d.path, s.Current)
+	for _, a := range s.Applied {
+		fmt.Printf("  aplicada  %04d_%s  %s\n", a.Version, a.Name, a.AppliedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	for _, m := range s.Pending {
+		fmt.Printf("  pendiente %s\n", m)
+	}
+
+	return nil
+}
+
+func migrationUp(d database, db *sql.DB) error {
+	done, err := d.up(db)
+	for _, m := range done {
+		fmt.Printf("%s (%s): aplicada %s\n", d.name, d.path, m)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(done) == 0 {
+		fmt.Printf("%s (%s): sin migraciones pendientes\n", d.name, d.path)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package browse
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/cmd/cabb/messages"
+)
+
+// Model walks the federation from delegations down to teams, to pick a team
+// to follow.
+type Model struct {
+	w, h   int
+	levels []list.Model
+
+	tournament cabb.Tournament
+	club       cabb.Club
+}
+
+func New(w, h int) Model {
+	return Model{w: w, h: h}
+}
+
+func (m Model) View() string {
+	if len(m.levels) == 0 {
+		return ""
+	}
+	return m.levels[len(m.levels)-1].View()
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case []cabb.Delegation:
+		items := make([]list.Item, len(msg))
+		for i, d := range msg {
+			items[i] = item{value: d, title: d.Name}
+		}
+		return m.push("Delegaciones", "delegación", "delegaciones", items), nil
+
+	case []cabb.Tournament:
+		items := make([]list.Item, len(msg))
+		for i, t := range msg {
+			items[i] = item{value: t, title: t.Name}
+		}
+		return m.push("Torneos", "torneo", "torneos", items), nil
+
+	case []cabb.Club:
+		items := make([]list.Item, len(msg))
+		for i, c := range msg {
+			items[i] = item{value: c, title: c.Name, desc: m.tournament.Name}
+		}
+		return m.push("Clubes", "club", "clubes", items), nil
+
+	case []cabb.Teams:
+		items := make([]list.Item, len(msg))
+		for i, t := range msg {
+			items[i] = item{value: t, title: t.Name, desc: m.club.Name}
+		}
+		return m.push("Equipos de "+m.club.Name, "equipo", "equipos", items), nil
+
+	case tea.KeyMsg:
+		if len(m.levels) == 0 {
+			return m, messages.Teams
+		}
+
+		l := &m.levels[len(m.levels)-1]
+
+		if l.FilterState() == list.Filtering {
+			break
+		}
+
+		switch msg.Type {
+		case tea.KeyEnter:
+			return m.selected(l.SelectedItem())
+
+		case tea.KeyEscape:
+			if l.FilterState() != list.Unfiltered {
+				break
+			}
+			m.levels = m.levels[:len(m.levels)-1]
+			if len(m.levels) == 0 {
+				return m, messages.Teams
+			}
+			return m, nil
+		}
+	}
+
+	if len(m.levels) == 0 {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.levels[len(m.levels)-1], cmd = m.levels[len(m.levels)-1].Update(msg)
+	return m, cmd
+}
+
+func (m Model) selected(i list.Item) (Model, tea.Cmd) {
+	it, ok := i.(item)
+	if !ok {
+		return m, nil
+	}
+
+	switch v := it.value.(type) {
+	case cabb.Delegation:
+		return m, messages.Load(v)
+
+	case cabb.Tournament:
+		m.tournament = v
+		return m, messages.Load(v)
+
+	case cabb.Club:
+		m.club = v
+		return m, messages.Load(messages.ClubMsg{Tournament: m.tournament, Club: v})
+
+	case cabb.Teams:
+		return m, messages.Follow(v.Team(m.club))
+	}
+
+	return m, nil
+}
+
+func (m Model) push(title, singular, plural string, items []list.Item) Model {
+	l := list.New(items, list.NewDefaultDelegate(), m.w, m.h)
+	l.Title = title
+	l.SetStatusBarItemName(singular, plural)
+	l.DisableQuitKeybindings()
+
+	// Don't modify the levels below, which are shared with previous models.
+	m.levels = append(m.levels[:len(m.levels):len(m.levels)], l)
+
+	return m
+}
+
+type item struct {
+	value       any
+	title, desc string
+}
+
+func (i item) Title() string       { return i.title }
+func (i item) Description() string { return i.desc }
+func (i item) FilterValue() string { return i.title }
@@ -2,18 +2,24 @@ package live
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/analysis"
 	"github.com/inkel/cabb/cmd/cabb/messages"
 )
 
 type Model struct {
-	live cabb.Live
-	view viewport.Model
+	live    cabb.Live
+	view    viewport.Model
+	err     error
+	lineups bool
 }
 
 func New(w, h int, l cabb.Live) Model {
@@ -30,10 +36,20 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case cabb.LiveEvent:
+		m = m.apply(msg)
+		return m, nil
+
 	case tea.KeyMsg:
 		if msg.String() == "g" {
 			return m, messages.LiveMatch(m.live.Match)
 		}
+		if msg.String() == "t" {
+			m.lineups = !m.lineups
+			m.view.SetContent(m.content())
+			m.view.GotoTop()
+			return m, nil
+		}
 		if msg.Type == tea.KeyEsc {
 			return m, messages.Back
 		}
@@ -45,9 +61,88 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
+	if m.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Top, m.view.View(), m.err.Error())
+	}
 	return m.view.View()
 }
 
+func (m Model) apply(e cabb.LiveEvent) Model {
+	m.err = nil
+
+	switch e.Kind {
+	case cabb.LiveAction:
+		m.live.Live.Actions = append(m.live.Live.Actions, e.Action)
+	case cabb.LiveScore:
+		m.live.LiveMatch.HomeScore, m.live.LiveMatch.AwayScore = e.HomeScore, e.AwayScore
+		m.live.LiveMatch.Periods = e.Periods
+	case cabb.LiveError:
+		m.err = e.Err
+		return m
+	}
+
+	m.view.SetContent(m.content())
+	if !m.lineups {
+		m.view.GotoBottom()
+	}
+
+	return m
+}
+
+func (m Model) content() string {
+	if m.lineups {
+		return lineups(m.live)
+	}
+	return live(m.live)
+}
+
+func lineups(l cabb.Live) string {
+	var s strings.Builder
+
+	home, away := analysis.Lineups(l)
+
+	for _, r := range []struct {
+		name string
+		rot  analysis.Rotation
+	}{{l.LiveMatch.Home, home}, {l.LiveMatch.Away, away}} {
+		nums := make(map[string]string)
+		for _, p := range r.rot.Players {
+			nums[p.ID] = p.Num
+		}
+
+		fmt.Fprintf(&s, "%s\n\n", r.name)
+
+		w := tabwriter.NewWriter(&s, 2, 2, 1, ' ', 0)
+
+		fmt.Fprintln(w, "QUINTETO\tMINUTOS\tPF\tPC\t+/-")
+		for _, lu := range r.rot.Lineups {
+			ns := make([]string, len(lu.Players))
+			for i, id := range lu.Players {
+				ns[i] = nums[id]
+			}
+			fmt.Fprintf(w, "%s\t%5.1f\t%d\t%d\t%+d\n", strings.Join(ns, " "), lu.Time.Minutes(), lu.For, lu.Against, lu.PlusMinus())
+		}
+
+		fmt.Fprintln(w, "\t\t\t\t")
+		fmt.Fprintln(w, "JUGADOR\tMINUTOS\t+/- EN CANCHA\t+/- AFUERA\t")
+		for _, p := range r.rot.Players {
+			fmt.Fprintf(w, "%s\t%5.1f\t%+d\t%+d\t\n", p.Num, p.Time.Minutes(), p.PlusMinus(), p.OffPlusMinus())
+		}
+
+		if err := w.Flush(); err != nil {
+			return err.Error()
+		}
+
+		for _, warn := range r.rot.Warnings {
+			fmt.Fprintf(&s, "Advertencia: %s\n", warn)
+		}
+
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
 func live(l cabb.Live) string {
 	var s strings.Builder
 
@@ -60,13 +155,20 @@ func live(l cabb.Live) string {
 
 	w := tabwriter.NewWriter(&s, 2, 2, 1, ' ', 0)
 
-	fmt.Fprintf(w, "CUARTO\t%s\tJUGADOR\t%s\n", ts[l.LiveMatch.HomeID], ts[l.LiveMatch.AwayID])
+	fmt.Fprintf(&s, "%s %d - %d %s\n\n", l.LiveMatch.Home, l.LiveMatch.HomeScore, l.LiveMatch.AwayScore, l.LiveMatch.Away)
+
+	fmt.Fprintf(w, "TIEMPO\t%s\tJUGADOR\t%s\n", ts[l.LiveMatch.HomeID], ts[l.LiveMatch.AwayID])
 
 	for _, a := range l.Live.Actions {
+		t := strconv.Itoa(a.Period)
+		if c, err := a.Clock(l.LiveMatch); err == nil {
+			t = c.String()
+		}
+
 		if a.TeamID == homeID {
-			fmt.Fprintf(w, "%d\t%s\t%s\t-\n", a.Period, a.Type, a.PlayerNum)
+			fmt.Fprintf(w, "%s\t%s\t%s\t-\n", t, a.Type, a.PlayerNum)
 		} else {
-			fmt.Fprintf(w, "%d\t-\t%s\t%s\n", a.Period, a.PlayerNum, a.Type)
+			fmt.Fprintf(w, "%s\t-\t%s\t%s\n", t, a.PlayerNum, a.Type)
 		}
 	}
 
@@ -74,5 +176,23 @@ func live(l cabb.Live) string {
 		return err.Error()
 	}
 
+	t := analysis.NewTimeline(l)
+
+	fmt.Fprintf(&s, "\nCambios de líder: %d - Empates: %d - Máxima ventaja: %s %d, %s %d\n",
+		t.LeadChanges, t.Ties, l.LiveMatch.Home, t.HomeLargestLead, l.LiveMatch.Away, t.AwayLargestLead)
+
+	for _, d := range t.Discrepancies {
+		fmt.Fprintf(&s, "Inconsistencia: %s\n", d)
+	}
+
+	if u := l.UnknownActionTypes(); len(u) > 0 {
+		ts := make([]string, 0, len(u))
+		for t, n := range u {
+			ts = append(ts, fmt.Sprintf("%s (%d)", t, n))
+		}
+		sort.Strings(ts)
+		fmt.Fprintf(&s, "\nAcciones desconocidas: %s\n", strings.Join(ts, ", "))
+	}
+
 	return s.String()
 }
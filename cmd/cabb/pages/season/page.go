@@ -1,6 +1,9 @@
 package season
 
 import (
+	"context"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -9,6 +12,9 @@ import (
 	"github.com/evertras/bubble-table/table"
 	"github.com/inkel/cabb"
 	"github.com/inkel/cabb/cmd/cabb/messages"
+	"github.com/inkel/cabb/ratings"
+	"github.com/inkel/cabb/simulation"
+	"github.com/inkel/cabb/standings"
 )
 
 type Model struct {
@@ -16,20 +22,52 @@ type Model struct {
 	dates  table.Model
 	games  table.Model
 	board  table.Model
+
+	inconsistencies []standings.Inconsistency
+	ratings         *ratings.Ratings
+
+	odds     table.Model
+	oddsErr  error
+	showOdds bool
+	width    int
+
+	// sim numbers the simulations, so the results of a cancelled one are
+	// ignored, and cancel stops the one in progress, if any.
+	sim    int
+	cancel context.CancelFunc
+}
+
+// oddsMsg carries the result of simulating the rest of the season.
+type oddsMsg struct {
+	sim  int
+	odds []simulation.Odds
+}
+
+// oddsErrMsg reports the simulation failed. It's kept apart from other errors
+// so only the odds view shows it.
+type oddsErrMsg struct {
+	sim int
+	err error
 }
 
 var (
 	tcs  = lipgloss.NewStyle().AlignHorizontal(lipgloss.Left)
 	ncs  = lipgloss.NewStyle().AlignHorizontal(lipgloss.Right)
 	bold = lipgloss.NewStyle().Bold(true)
+	warn = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
 )
 
-func NewModel(w, h int, s cabb.Season) Model {
+func NewModel(w, h int, s cabb.Season, r *ratings.Ratings) Model {
+	is := standings.Diff(standings.Compute(s), s.Positions)
+
 	m := Model{
-		season: s,
-		dates:  datesTable(w/2, h/2, s.Season),
-		board:  boardTable(w/2, s.Positions),
-		games:  gamesTable(w, h/2),
+		season:          s,
+		dates:           datesTable(w/2, h/2, s.Season),
+		board:           boardTable(w/2, s.Positions, is, r),
+		games:           gamesTable(w, h/2),
+		inconsistencies: is,
+		ratings:         r,
+		width:           w / 2,
 	}
 
 	for _, gm := range s.Season {
@@ -58,7 +96,32 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case oddsMsg:
+		if msg.sim == m.sim {
+			m = m.done()
+			m.odds = oddsTable(m.width, msg.odds)
+		}
+		return m, nil
+
+	case oddsErrMsg:
+		if msg.sim == m.sim {
+			m = m.done()
+			m.oddsErr = msg.err
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		if msg.String() == "s" {
+			m.showOdds = !m.showOdds
+			if m.showOdds && m.cancel == nil && len(m.odds.GetVisibleRows()) == 0 {
+				ctx, cancel := context.WithCancel(context.Background())
+				m.sim++
+				m.cancel, m.oddsErr = cancel, nil
+				return m, m.simulate(ctx, m.sim)
+			}
+			return m, nil
+		}
+
 		switch msg.Type {
 		case tea.KeyTab:
 			m.dates = m.dates.Focused(!m.dates.GetFocused())
@@ -66,14 +129,14 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 		case tea.KeyEnter:
 			if m.games.GetFocused() {
-				return m, messages.Load(m.games.HighlightedRow().Data["Match"])
+				return m.Stop(), messages.Load(m.games.HighlightedRow().Data["Match"])
 			}
 
 		case tea.KeyEscape:
-			return m, messages.Back
+			return m.Stop(), messages.Back
 
 		case tea.KeyCtrlR:
-			return m, messages.Load(cabb.Team{ID: m.season.TeamID})
+			return m.Stop(), messages.Load(cabb.Team{ID: m.season.TeamID})
 
 		case tea.KeyUp, tea.KeyDown:
 			if m.dates.GetFocused() {
@@ -89,8 +152,27 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 func (m Model) View() string {
 	var s strings.Builder
 
+	if len(m.inconsistencies) > 0 {
+		s.WriteString(warn.Render("La clasificación no coincide con los resultados:"))
+		for _, i := range m.inconsistencies {
+			s.WriteString("\n  " + i.String())
+		}
+	}
+
+	board := m.board.View()
+	if m.showOdds {
+		switch {
+		case m.oddsErr != nil:
+			board = warn.Render("No se pudo simular la temporada: " + m.oddsErr.Error())
+		case len(m.odds.GetVisibleRows()) > 0:
+			board = m.odds.View()
+		default:
+			board = "Simulando temporada..."
+		}
+	}
+
 	return lipgloss.JoinVertical(lipgloss.Top,
-		lipgloss.JoinHorizontal(lipgloss.Left, m.dates.View(), m.board.View()),
+		lipgloss.JoinHorizontal(lipgloss.Left, m.dates.View(), board),
 		m.games.View(), s.String())
 }
 
@@ -124,7 +206,7 @@ func datesTable(w, h int, dates []cabb.GameDay) table.Model {
 		WithHighlightedRow(hl)
 }
 
-func boardTable(width int, pos []cabb.Position) table.Model {
+func boardTable(width int, pos []cabb.Position, is []standings.Inconsistency, r *ratings.Ratings) table.Model {
 	cols := []table.Column{
 		table.NewFlexColumn("Name", "Nombre", 2).WithStyle(tcs),
 		table.NewColumn("PJ", "PJ", 2).WithStyle(ncs),
@@ -136,6 +218,19 @@ func boardTable(width int, pos []cabb.Position) table.Model {
 		table.NewColumn("APC", "PPC", 3).WithStyle(ncs),
 		table.NewColumn("APD", "PDP", 4).WithStyle(ncs),
 		table.NewColumn("PS", "Puntos", 6).WithStyle(ncs),
+		table.NewColumn("Elo", "Elo", 5).WithStyle(ncs),
+	}
+
+	avg := func(n, played int) int {
+		if played == 0 {
+			return 0
+		}
+		return n / played
+	}
+
+	wrong := make(map[string]bool, len(is))
+	for _, i := range is {
+		wrong[i.Team] = true
 	}
 
 	rows := make([]table.Row, len(pos))
@@ -148,11 +243,93 @@ func boardTable(width int, pos []cabb.Position) table.Model {
 			"PP":   p.Lost,
 			"PF":   p.Scored,
 			"PC":   p.Received,
-			"APF":  p.Scored / p.Played,
-			"APC":  p.Received / p.Played,
-			"APD":  (p.Scored - p.Received) / p.Played,
+			"APF":  avg(p.Scored, p.Played),
+			"APC":  avg(p.Received, p.Played),
+			"APD":  avg(p.Scored-p.Received, p.Played),
 			"PS":   p.Score,
 		})
+		if r != nil {
+			rows[i].Data["Elo"] = fmt.Sprintf("%.0f", r.Rating(p.Name))
+		}
+		if wrong[p.Name] {
+			rows[i] = rows[i].WithStyle(warn)
+		}
+	}
+
+	return table.New(cols).WithRows(rows).WithTargetWidth(width).Focused(false)
+}
+
+// simulate plays the rest of the season. Half of the teams qualify and the
+// last one is relegated, as there's no way to know the actual rules of each
+// tournament. The computed standings leave byes out, so only real teams are
+// counted.
+func (m Model) simulate(ctx context.Context, sim int) tea.Cmd {
+	return func() tea.Msg {
+		n := len(standings.Compute(m.season))
+
+		opts := []simulation.Option{simulation.Qualify(n / 2), simulation.Relegate(1)}
+		if m.ratings != nil {
+			opts = append(opts, simulation.WithModel(m.ratings))
+		}
+
+		res, err := simulation.Simulate(ctx, m.season, opts...)
+		if err != nil {
+			return oddsErrMsg{sim, err}
+		}
+
+		return oddsMsg{sim, res}
+	}
+}
+
+// Stop cancels the simulation in progress, if any, and hides the odds so
+// they're simulated again when shown.
+func (m Model) Stop() Model {
+	if m.cancel != nil {
+		m.sim++
+	}
+	m = m.done()
+	m.showOdds = false
+	return m
+}
+
+// done releases the context of the simulation in progress.
+func (m Model) done() Model {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.cancel = nil
+	return m
+}
+
+func oddsTable(width int, os []simulation.Odds) table.Model {
+	pct := func(f float64) string {
+		return fmt.Sprintf("%.1f", 100*f)
+	}
+
+	cols := []table.Column{
+		table.NewFlexColumn("Name", "Nombre", 2).WithStyle(tcs),
+	}
+	for i := range os {
+		cols = append(cols, table.NewColumn(strconv.Itoa(i), fmt.Sprintf("%dº", i+1), 5).WithStyle(ncs))
+	}
+	cols = append(cols,
+		table.NewColumn("Q", "Clasif", 6).WithStyle(ncs),
+		table.NewColumn("R", "Desc", 5).WithStyle(ncs),
+		table.NewColumn("PS", "Puntos", 6).WithStyle(ncs),
+	)
+
+	rows := make([]table.Row, len(os))
+	for i, o := range os {
+		data := table.RowData{
+			"Name": o.Team,
+			"Q":    pct(o.Qualify),
+			"R":    pct(o.Relegate),
+			"PS":   fmt.Sprintf("%.1f", o.Points),
+		}
+		for p, f := range o.Positions {
+			data[strconv.Itoa(p)] = pct(f)
+		}
+		rows[i] = table.NewRow(data)
 	}
 
 	return table.New(cols).WithRows(rows).WithTargetWidth(width).Focused(false)
@@ -166,6 +343,7 @@ func gamesTable(w, h int) table.Model {
 		table.NewColumn("AS", "#", 3).WithStyle(ncs),
 		table.NewFlexColumn("Away", "Visitante", 2).WithStyle(tcs),
 		table.NewFlexColumn("Status", "Estado", 1).WithStyle(tcs),
+		table.NewColumn("Prob", "%L", 5).WithStyle(ncs),
 	}
 
 	return table.New(cols).
@@ -176,22 +354,22 @@ func gamesTable(w, h int) table.Model {
 func (m Model) withGames(games []cabb.Match) Model {
 	rows := make([]table.Row, len(games))
 	for i, g := range games {
-		var d, t string
-		if g.Date != "" {
-			d = g.Date[0:5]
-		}
-		if g.Time != "" {
-			t = g.Time[0:5]
+		d := g.Date
+		if !g.Kickoff.IsZero() {
+			d = g.Kickoff.Format("02/01 15:04")
 		}
 		rows[i] = table.NewRow(table.RowData{
 			"Match":  g,
-			"Date":   d + " " + t,
+			"Date":   d,
 			"Home":   g.HomeTeam,
-			"HS":     g.HomeScore,
-			"AS":     g.AwayScore,
+			"HS":     g.HomePoints.String(),
+			"AS":     g.AwayPoints.String(),
 			"Away":   g.AwayTeam,
 			"Status": g.Status,
 		})
+		if m.ratings != nil && !g.Played() {
+			rows[i].Data["Prob"] = fmt.Sprintf("%.0f", 100*m.ratings.WinProbability(g))
+		}
 	}
 	m.games = m.games.WithRows(rows)
 	return m
@@ -2,6 +2,7 @@ package stats
 
 import (
 	"fmt"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -64,9 +65,16 @@ func (m Model) View() string {
 	return lipgloss.JoinVertical(lipgloss.Top,
 		m.stats.MatchID,
 		fmt.Sprintf("%s %3d - %3d %s", m.stats.Match.Home, m.stats.Match.HomeScore, m.stats.Match.AwayScore, m.stats.Match.Away),
+		advanced(m.stats),
 		data)
 }
 
+func advanced(s cabb.Stats) string {
+	home, away := s.OffensiveRating()
+	return fmt.Sprintf("Posesiones: %.1f  Ritmo: %.1f  Ofensiva: %.1f - %.1f",
+		s.Possessions(), s.Pace(), home, away)
+}
+
 var (
 	tcs = lipgloss.NewStyle().AlignHorizontal(lipgloss.Left)
 	ncs = lipgloss.NewStyle().AlignHorizontal(lipgloss.Right)
@@ -95,6 +103,12 @@ func playerStats(w int, players []cabb.PlayerStats) table.Model {
 		nc("RBO", "RO", 2),
 		nc("RBD", "RD", 2),
 		nc("VAL", "VAL", 3),
+		nc("EFG", "eFG%", 5),
+		nc("TS", "TS%", 5),
+		nc("USG", "USO%", 5),
+		nc("PTS40", "PS/40", 5),
+		nc("RB40", "RT/40", 5),
+		nc("A40", "AS/40", 5),
 	}
 
 	shots := func(m, a int) string {
@@ -105,6 +119,16 @@ func playerStats(w int, players []cabb.PlayerStats) table.Model {
 		return fmt.Sprintf("%2d/%2d (%.2f)", m, a, p)
 	}
 
+	pct := func(f float64) string {
+		return fmt.Sprintf("%.1f", 100*f)
+	}
+
+	per40 := func(p cabb.PlayerStats, v int) string {
+		return fmt.Sprintf("%.1f", p.Per(v, 40*time.Minute))
+	}
+
+	team := cabb.Totals(players)
+
 	height := len(players)
 	rows := make([]table.Row, height)
 	for i, p := range players {
@@ -124,10 +148,20 @@ func playerStats(w int, players []cabb.PlayerStats) table.Model {
 			"1P":     shots(p.Made1P, p.Shots1P),
 			"3P":     shots(p.Made3P, p.Shots3P),
 			"VAL":    p.Val,
+			"EFG":    pct(p.EFG()),
+			"TS":     pct(p.TS()),
+			"USG":    pct(p.Usage(team)),
+			"PTS40":  per40(p, p.Points),
+			"RB40":   per40(p, p.Rebounds),
+			"A40":    per40(p, p.Assists),
 		})
-		if p.Num == "" && p.Name == "TOTALES" {
+		if p.IsTotals() {
 			rows[i] = rows[i].WithStyle(totals)
 			delete(rows[i].Data, "Played")
+			delete(rows[i].Data, "USG")
+			delete(rows[i].Data, "PTS40")
+			delete(rows[i].Data, "RB40")
+			delete(rows[i].Data, "A40")
 		}
 	}
 
@@ -1,6 +1,9 @@
 package teams
 
 import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -9,11 +12,17 @@ import (
 )
 
 type Model struct {
-	list list.Model
+	list    list.Model
+	confirm string
 }
 
 var tableDefaultStyle = lipgloss.NewStyle().AlignHorizontal(lipgloss.Left)
 
+var (
+	followKey   = key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "seguir equipo"))
+	unfollowKey = key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "dejar de seguir"))
+)
+
 func NewModel(w, h int, teams []cabb.Team) Model {
 	items := make([]list.Item, len(teams))
 	for i, t := range teams {
@@ -24,6 +33,9 @@ func NewModel(w, h int, teams []cabb.Team) Model {
 	l.Title = "Mis Equipos"
 	l.SetStatusBarItemName("equipo", "equipos")
 	l.DisableQuitKeybindings()
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{followKey, unfollowKey}
+	}
 
 	return Model{
 		list: l,
@@ -35,12 +47,31 @@ func (m Model) View() string {
 }
 
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
-	if msg, ok := msg.(tea.KeyMsg); ok {
-		switch msg.Type {
-		case tea.KeyEnter:
-			i := m.list.SelectedItem().(item)
-			return m, messages.Load(i.team)
+	if msg, ok := msg.(tea.KeyMsg); ok && m.list.FilterState() != list.Filtering {
+		switch {
+		case msg.Type == tea.KeyEnter:
+			if i, ok := m.list.SelectedItem().(item); ok {
+				return m, messages.Load(i.team)
+			}
+			return m, nil
+
+		case key.Matches(msg, followKey):
+			return m, messages.Browse
+
+		case key.Matches(msg, unfollowKey):
+			i, ok := m.list.SelectedItem().(item)
+			if !ok {
+				return m, nil
+			}
+			if m.confirm == i.team.ID {
+				m.confirm = ""
+				return m, messages.Unfollow(i.team)
+			}
+			m.confirm = i.team.ID
+			return m, m.list.NewStatusMessage(fmt.Sprintf("Presioná x de nuevo para dejar de seguir a %s", i.team.Name))
 		}
+
+		m.confirm = ""
 	}
 
 	var cmd tea.Cmd
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/inkel/cabb/players"
+	"github.com/inkel/cabb/store"
+)
+
+func playersCmd(args []string) error {
+	fs := flag.NewFlagSet("players", flag.ExitOnError)
+	storePath := fs.String("store", "cabb.db", "path of the store database")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: cabb players [flags] command
+
+Commands:
+  index                  links the players of every match in the store
+  list TEAM              lists the players of a team
+  suggest TEAM           lists players of a team that might be the same
+  stats TEAM             shows the stats of every player of a team
+  merge ID FROM          merges the player FROM into the player ID
+  split ID NUM NAME      moves the number and name of a player to a new one
+  distinct ID OTHER      marks two players as different people
+
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	db, err := store.Open(*storePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	reg := players.New(db)
+
+	cmd, rest := fs.Arg(0), fs.Args()
+	if len(rest) > 0 {
+		rest = rest[1:]
+	}
+	team := strings.Join(rest, " ")
+
+	switch {
+	case cmd == "index" && len(rest) == 0:
+		return reg.IndexStore()
+	case cmd == "list" && team != "":
+		return listPlayers(reg, team)
+	case cmd == "suggest" && team != "":
+		return suggestPlayers(reg, team)
+	case cmd == "stats" && team != "":
+		return playerTotals(reg, team)
+	case cmd == "merge" && len(rest) == 2:
+		ids, err := playerIDs(rest)
+		if err != nil {
+			return err
+		}
+		return reg.Merge(ids[0], ids[1])
+	case cmd == "split" && len(rest) >= 3:
+		ids, err := playerIDs(rest[:1])
+		if err != nil {
+			return err
+		}
+		p, err := reg.Split(ids[0], players.Key{Num: rest[1], Name: strings.Join(rest[2:], " ")})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Nuevo jugador %d: %s\n", p.ID, p.Name)
+		return nil
+	case cmd == "distinct" && len(rest) == 2:
+		ids, err := playerIDs(rest)
+		if err != nil {
+			return err
+		}
+		return reg.Distinct(ids[0], ids[1])
+	}
+
+	fs.Usage()
+	os.Exit(2)
+
+	return nil
+}
+
+func playerIDs(args []string) ([]int64, error) {
+	ids := make([]int64, len(args))
+	for i, a := range args {
+		id, err := strconv.ParseInt(a, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid player ID %q", a)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func listPlayers(reg *players.Registry, team string) error {
+	ps, err := reg.Players(team)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "ID\tJUGADOR\tNÚMEROS\tNOMBRES\tCOMPONENTES")
+	for _, p := range ps {
+		var names []string
+		for _, k := range p.Keys {
+			if !contains(names, k.Name) {
+				names = append(names, k.Name)
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", p.ID, p.Name,
+			strings.Join(p.Nums(), ", "), strings.Join(names, ", "), strings.Join(p.Actors, ", "))
+	}
+
+	return w.Flush()
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func suggestPlayers(reg *players.Registry, team string) error {
+	ss, err := reg.Suggestions(team)
+	if err != nil {
+		return err
+	}
+
+	if len(ss) == 0 {
+		fmt.Println("Sin sugerencias")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "SIMILITUD\tID\tJUGADOR\tNÚMEROS\tID\tJUGADOR\tNÚMEROS")
+	for _, s := range ss {
+		fmt.Fprintf(w, "%5.2f\t%d\t%s\t%s\t%d\t%s\t%s\n", s.Score,
+			s.A.ID, s.A.Name, strings.Join(s.A.Nums(), ", "),
+			s.B.ID, s.B.Name, strings.Join(s.B.Nums(), ", "))
+	}
+
+	return w.Flush()
+}
+
+func playerTotals(reg *players.Registry, team string) error {
+	ts, err := reg.Aggregate(team)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "ID\tJUGADOR\tNÚMEROS\tPARTIDOS\tMINUTOS\tPUNTOS\tREBOTES\tASISTENCIAS")
+	for _, t := range ts {
+		s := t.Stats
+		gp := float64(t.Games)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%2d\t%5.2f\t%4d (%5.2f)\t%4d (%5.2f)\t%3d (%5.2f)\n",
+			t.Player.ID, t.Player.Name, strings.Join(t.Player.Nums(), ", "), t.Games,
+			s.PlayedDuration().Minutes()/gp,
+			s.Points, float64(s.Points)/gp,
+			s.Rebounds, float64(s.Rebounds)/gp,
+			s.Assists, float64(s.Assists)/gp,
+		)
+	}
+
+	return w.Flush()
+}
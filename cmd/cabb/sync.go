@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/players"
+	"github.com/inkel/cabb/store"
+)
+
+// syncResult is the outcome of syncing a match.
+type syncResult int
+
+const (
+	syncAdded syncResult = iota
+	syncUpdated
+	syncNoStats
+	syncFailed
+)
+
+type syncSummary struct {
+	mu sync.Mutex
+
+	teams     int
+	unchanged int
+	matches   map[syncResult][]string
+	errs      []error
+}
+
+func (s *syncSummary) add(r syncResult, m cabb.Match, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.matches == nil {
+		s.matches = make(map[syncResult][]string)
+	}
+	s.matches[r] = append(s.matches[r], m.Title())
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %w", m.Title(), err))
+	}
+}
+
+func (s *syncSummary) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+func (s *syncSummary) print() {
+	fmt.Printf("Equipos sincronizados: %d\n", s.teams)
+	fmt.Printf("Partidos sin cambios: %d\n", s.unchanged)
+
+	sections := []struct {
+		r     syncResult
+		title string
+	}{
+		{syncAdded, "Partidos nuevos"},
+		{syncUpdated, "Partidos actualizados"},
+		{syncNoStats, "Partidos sin estadísticas"},
+		{syncFailed, "Partidos con errores"},
+	}
+
+	for _, sec := range sections {
+		ms := s.matches[sec.r]
+		sort.Strings(ms)
+
+		fmt.Printf("%s: %d\n", sec.title, len(ms))
+		for _, m := range ms {
+			fmt.Printf("  %s\n", m)
+		}
+	}
+
+	for _, err := range s.errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func syncCmd(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	storePath := fs.String("store", "cabb.db", "path of the store database")
+	workers := fs.Int("workers", 4, "number of concurrent requests")
+	live := fs.Bool("live", false, "also sync the play-by-play of each match")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: cabb sync [flags] [team ID...]\n\nSyncs the followed teams if no team ID is given.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *workers < 1 {
+		*workers = 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c, closeClient, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	db, err := store.Open(*storePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	teams, err := syncTeams(ctx, c, db, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	s := &syncer{client: c, db: db, live: *live}
+
+	seasons := s.seasons(ctx, teams, *workers)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.matches(ctx, seasons, *workers)
+
+	// Index once every match is stored, in the order they were stored, so
+	// player IDs don't depend on which worker finished first.
+	if err := players.New(db).IndexStore(); err != nil {
+		s.summary.fail(fmt.Errorf("indexing players: %w", err))
+	}
+
+	s.summary.print()
+
+	if len(s.summary.errs) > 0 {
+		return errors.New("sync finished with errors")
+	}
+
+	return ctx.Err()
+}
+
+// syncTeams returns the teams to sync: the given IDs or every followed team.
+func syncTeams(ctx context.Context, c *cabb.Client, db *store.Store, ids []string) ([]cabb.Team, error) {
+	if len(ids) > 0 {
+		ts := make([]cabb.Team, len(ids))
+		for i, id := range ids {
+			ts[i] = cabb.Team{ID: id, Name: id}
+		}
+		return ts, nil
+	}
+
+	ts, err := c.TeamsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching followed teams: %w", err)
+	}
+
+	for _, t := range ts {
+		if err := db.SaveTeam(t); err != nil {
+			return nil, fmt.Errorf("saving team %s: %w", t.Name, err)
+		}
+	}
+
+	return ts, nil
+}
+
+type syncer struct {
+	client *cabb.Client
+	db     *store.Store
+	live   bool
+
+	summary syncSummary
+}
+
+// pool runs f for every item of n using at most workers goroutines.
+func pool(ctx context.Context, n, workers int, f func(i int)) {
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan int)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+// seasons fetches and stores the season of every team, returning them.
+func (s *syncer) seasons(ctx context.Context, teams []cabb.Team, workers int) []cabb.Season {
+	var (
+		mu      sync.Mutex
+		seasons []cabb.Season
+	)
+
+	pool(ctx, len(teams), workers, func(i int) {
+		t := teams[i]
+
+		season, err := s.client.SeasonContext(ctx, t.ID)
+		if err == nil {
+			err = s.db.SaveSeason(t.ID, season)
+		}
+		if err != nil {
+			s.summary.fail(fmt.Errorf("season of %s: %w", t.Name, err))
+			return
+		}
+
+		mu.Lock()
+		seasons = append(seasons, season)
+		s.summary.teams++
+		mu.Unlock()
+	})
+
+	return seasons
+}
+
+// pending returns the matches of the seasons that need to be fetched, without
+// duplicates, as teams of the same tournament share matches.
+func (s *syncer) pending(seasons []cabb.Season) []cabb.Match {
+	var (
+		ms   []cabb.Match
+		seen = make(map[string]bool)
+	)
+
+	for _, season := range seasons {
+		for _, gd := range season.Season {
+			for _, m := range gd.Matches {
+				if m.MatchID == "" || seen[m.MatchID] || m.IsBye() {
+					continue
+				}
+				seen[m.MatchID] = true
+
+				if !started(m) {
+					continue
+				}
+
+				st, ok, err := s.db.SyncState(m.MatchID)
+				if err != nil {
+					s.summary.add(syncFailed, m, err)
+					continue
+				}
+
+				// When a final match had no stats it won't have them later
+				// either.
+				if ok && st.Done(m) {
+					s.summary.unchanged++
+					continue
+				}
+
+				ms = append(ms, m)
+			}
+		}
+	}
+
+	return ms
+}
+
+// started reports whether m may already have stats.
+func started(m cabb.Match) bool {
+	switch m.State {
+	case cabb.StatusLive, cabb.StatusFinished, cabb.StatusForfeit:
+		return true
+	}
+	return m.Played()
+}
+
+// matches fetches and stores the stats of the matches that changed.
+func (s *syncer) matches(ctx context.Context, seasons []cabb.Season, workers int) {
+	ms := s.pending(seasons)
+
+	pool(ctx, len(ms), workers, func(i int) {
+		m := ms[i]
+
+		_, existed, err := s.db.SyncState(m.MatchID)
+		if err != nil {
+			s.summary.add(syncFailed, m, err)
+			return
+		}
+
+		err = s.fetch(ctx, m)
+
+		state := store.NewSyncState(m, time.Now(), err)
+		if serr := s.db.SaveSyncState(state); serr != nil && err == nil {
+			err = serr
+		}
+
+		switch {
+		case cabb.IsNoStats(err):
+			s.summary.add(syncNoStats, m, nil)
+		case err != nil:
+			s.summary.add(syncFailed, m, err)
+		case existed:
+			s.summary.add(syncUpdated, m, nil)
+		default:
+			s.summary.add(syncAdded, m, nil)
+		}
+	})
+}
+
+func (s *syncer) fetch(ctx context.Context, m cabb.Match) error {
+	st, err := s.client.StatsContext(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.SaveStats(st); err != nil {
+		return err
+	}
+
+	if !s.live {
+		return nil
+	}
+
+	// Not every match has play-by-play, which shouldn't make the stats count
+	// as missing.
+	l, err := s.client.LiveContext(ctx, m)
+	if cabb.IsNoStats(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("play-by-play: %w", err)
+	}
+
+	return s.db.SaveLive(l)
+}
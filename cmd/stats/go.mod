@@ -4,9 +4,9 @@ go 1.21.1
 
 require (
 	github.com/inkel/cabb v0.0.1
-	github.com/jmoiron/sqlx v1.3.5
+	github.com/jmoiron/sqlx v1.3.5 // indirect
 )
 
-require github.com/mattn/go-sqlite3 v1.14.18
+require github.com/mattn/go-sqlite3 v1.14.18 // indirect
 
-replace github.com/inkel/cabb => /Users/inkel/dev/go/src/github.com/inkel/cabb
+replace github.com/inkel/cabb => ../..
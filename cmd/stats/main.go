@@ -7,15 +7,15 @@ import (
 	"fmt"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
 	"text/tabwriter"
 	"text/template"
 	"time"
 
 	"github.com/inkel/cabb"
-	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/inkel/cabb/analysis"
+	"github.com/inkel/cabb/players"
+	"github.com/inkel/cabb/store"
 )
 
 func dieIf(err error) {
@@ -39,49 +39,40 @@ var (
 	uid      = mustEnv("CABBUID")
 )
 
-const schema = `
-CREATE TABLE IF NOT EXISTS teams (
-       id TEXT PRIMARY KEY,
-       club TEXT NOT NULL,
-       name TEXT NOT NULL,
-       notificationId TEXT
-);
-
-CREATE TABLE IF NOT EXISTS seasons (
-       id INTEGER PRIMARY KEY AUTOINCREMENT,
-       teamId TEXT REFERENCES teams (id) NOT NULL
-);
-
-CREATE UNIQUE INDEX IF NOT EXISTS season_team_id ON seasons (teamId);
-
-CREATE TABLE IF NOT EXISTS gamedays (
-       id INTEGER PRIMARY KEY AUTOINCREMENT,
-       seasonId INT REFERENCES seasons (id) NOT NULL,
-       name TEXT NOT NULL,
-       date TEXT NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS match_results (
-       id INTEGER PRIMARY KEY AUTOINCREMENT,
-       matchId TEXT UNIQUE NOT NULL,
-       homeTeam TEXT NOT NULL,
-       awayTeam TEXT NOT NULL,
-       homeScore INTEGER NOT NULL,
-       awayScore INTEGER NOT NULL,
-       date TEXT NOT NULL,
-       status TEXT NOT NULL
-);
-`
-
 type playerStats struct {
 	cabb.PlayerStats
 	GamesPlayed int
+
+	// Player is the registry ID of the player, 0 for the team totals.
+	Player int64
 }
 
-func (s playerStats) FGMade() int  { return s.Made2P + s.Made3P }
-func (s playerStats) FGShots() int { return s.Shots2P + s.Shots3P }
+// teamStats are the stats of the players of the team keyed by their registry
+// ID, so players sharing a name or changing their number aren't mixed.
+type teamStats map[int64]playerStats
+
+// sorted returns the stats of every player sorted by name, adding their
+// numbers to the names shared by more than one player.
+func (ts teamStats) sorted(ps map[int64]players.Player) []playerStats {
+	names := make(map[string]int)
+	for _, p := range ps {
+		names[p.Name]++
+	}
 
-type teamStats map[string]playerStats
+	res := make([]playerStats, 0, len(ts))
+	for id, s := range ts {
+		p := ps[id]
+		s.Name = p.Name
+		if names[p.Name] > 1 {
+			s.Name += " #" + strings.Join(p.Nums(), "/")
+		}
+		res = append(res, s)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+
+	return res
+}
 
 type match struct {
 	cabb.Match
@@ -89,16 +80,14 @@ type match struct {
 
 type matches []match
 
-func atoi(s string) int {
-	n, err := strconv.Atoi(s)
-	dieIf(err)
-	return n
-}
-
 func (ms matches) Stats(team string) TeamStats {
 	var res TeamStats
 	for _, m := range ms {
-		home, away := atoi(m.HomeScore), atoi(m.AwayScore)
+		if !m.Played() {
+			continue
+		}
+		home, away := m.HomePoints.Points(), m.AwayPoints.Points()
+		res.Played += 1
 		if m.HomeTeam == team {
 			if home > away {
 				res.Won += 1
@@ -122,15 +111,64 @@ func (ms matches) Stats(team string) TeamStats {
 }
 
 type TeamStats struct {
-	Won, Lost        int
-	Scored, Received int
+	Played, Won, Lost int
+	Scored, Received  int
 }
 
 type templateData struct {
 	Matches     matches
-	PlayerStats teamStats
+	PlayerStats []playerStats
+	TeamTotals  playerStats
 
 	Team, TeamID string
+
+	// Totals and Opponents hold the box score totals of the team and its
+	// rivals over every match with stats, which lasted Length in total.
+	Totals, Opponents cabb.PlayerStats
+	Length            time.Duration
+
+	Stats []cabb.Stats
+}
+
+// Factors returns the Four Factors of the team over the whole season.
+func (d templateData) Factors() analysis.FourFactors {
+	return analysis.SeasonFactors(d.Team, d.Stats)
+}
+
+// MatchFactors returns the Four Factors of the team in each match with stats,
+// with the opponent's name as Rival.
+func (d templateData) MatchFactors() []matchFactors {
+	fs := make([]matchFactors, len(d.Stats))
+	for i, s := range d.Stats {
+		home, away := analysis.MatchFactors(s)
+		if away.Team == d.Team {
+			home, away = away, home
+		}
+		fs[i] = matchFactors{home, away.Team}
+	}
+	return fs
+}
+
+type matchFactors struct {
+	analysis.FourFactors
+	Rival string
+}
+
+func (d templateData) Usage(s playerStats) float64 { return s.Usage(d.Totals) }
+
+func (d templateData) Pace() float64 {
+	if d.Length == 0 {
+		return 0
+	}
+	return (d.Totals.Possessions() + d.Opponents.Possessions()) / 2 * 40 / d.Length.Minutes()
+}
+
+func (d templateData) OffensiveRating() float64 {
+	return cabb.OffensiveRating(d.Totals, d.Opponents)
+}
+
+func (d templateData) DefensiveRating() float64 {
+	return cabb.DefensiveRating(d.Totals, d.Opponents)
 }
 
 func main() {
@@ -139,33 +177,36 @@ func main() {
 	flag.BoolVar(&html, "html", false, "Output")
 	flag.Parse()
 
-	c, err := cabb.NewClient(uid, deviceID)
-	dieIf(err)
+	retry := cabb.DefaultRetryPolicy
+	retry.MaxAttempts = 5
+	retry.OnRetry = func(a cabb.RetryAttempt) {
+		fmt.Fprintf(os.Stderr, "Reintentando %s en %v (intento %d): %v\n", a.Endpoint, a.Delay, a.Attempt, a.Err)
+	}
 
-	s, err := c.Season(teamID)
-	dieIf(err)
+	opts := []cabb.Option{cabb.WithRetryPolicy(retry)}
+	if u := os.Getenv("CABBURL"); u != "" {
+		opts = append(opts, cabb.WithBaseURL(u))
+	}
 
-	db, err := sqlx.Connect("sqlite3", "cabb.db")
+	c, err := cabb.NewClient(uid, deviceID, opts...)
 	dieIf(err)
-	defer db.Close()
 
-	_, err = db.Exec(schema)
+	s, err := c.Season(teamID)
 	dieIf(err)
 
-	var seasonID int64
-
-	err = db.QueryRowx("SELECT id FROM seasons WHERE teamId = $1", teamID).Scan(&seasonID)
+	db, err := store.Open("cabb.db")
 	dieIf(err)
+	defer db.Close()
 
-	if seasonID == 0 {
-		res, err := db.Exec("INSERT INTO seasons (teamId) VALUES ($1)", teamID)
-		dieIf(err)
+	dieIf(db.SaveSeason(teamID, s))
 
-		seasonID, err = res.LastInsertId()
-		dieIf(err)
-	}
+	reg := players.New(db)
 
-	ss := make(teamStats)
+	var (
+		ss     = make(teamStats)
+		roster = make(map[int64]players.Player)
+		tot    = playerStats{PlayerStats: cabb.PlayerStats{Name: "TOTALES"}}
+	)
 
 	data := templateData{
 		Team:   defe,
@@ -173,35 +214,11 @@ func main() {
 	}
 
 	for _, gm := range s.Season {
-		var gmID int64
-		err := db.QueryRowx("SELECT id FROM gamedays WHERE seasonId = $1 AND name = $2", seasonID, gm.Name).Scan(&gmID)
-		dieIf(err)
-
-		if gmID == 0 {
-			res, err := db.Exec("INSERT INTO gamedays (seasonID, name, date) VALUES ($1, $2, $3)", seasonID, gm.Name, gm.Date)
-			dieIf(err)
-
-			gmID, err = res.LastInsertId()
-			dieIf(err)
-		}
-
 		for _, m := range gm.Matches {
-			if m.HomeTeam == "LIBRE" || m.AwayTeam == "LIBRE" {
+			if m.IsBye() {
 				continue
 			}
 
-			var matchID int64
-			err := db.QueryRowx("SELECT id FROM match_results WHERE matchID = $1", m.MatchID).Scan(&matchID)
-			dieIf(err)
-
-			if matchID == 0 {
-				res, err := db.NamedExec("INSERT INTO match_results (matchId, homeTeam, awayTeam, homeScore, awayScore, date, status) VALUES (:match_id, :home_team, :away_team, :home_score, :away_score, :date, :status)", m)
-				dieIf(err)
-
-				matchID, err = res.LastInsertId()
-				dieIf(err)
-			}
-
 			if m.HomeTeam == defe || m.AwayTeam == defe {
 				if !html {
 					fmt.Printf("Analizando %s\n", m.Title())
@@ -210,57 +227,57 @@ func main() {
 				data.Matches = append(data.Matches, match{m})
 
 				s, err := c.Stats(m)
+				if cabb.IsNoStats(err) {
+					fmt.Fprintf(os.Stderr, "Sin estadísticas para %s: %v\n", m.Title(), err)
+					continue
+				}
 				dieIf(err)
+				dieIf(db.SaveStats(s))
+				dieIf(reg.IndexStats(s))
 
-				var ps []cabb.PlayerStats
+				var ps, opp []cabb.PlayerStats
 
 				if s.Match.Home == defe {
-					ps = s.Stats.Home
+					ps, opp = s.Stats.Home, s.Stats.Away
 				} else {
-					ps = s.Stats.Away
+					ps, opp = s.Stats.Away, s.Stats.Home
 				}
 
+				data.Totals = data.Totals.Add(cabb.Totals(ps))
+				data.Opponents = data.Opponents.Add(cabb.Totals(opp))
+				data.Length += s.Match.Duration()
+				data.Stats = append(data.Stats, s)
+
 				for _, p := range ps {
 					if p.PlayedMillis == 0 {
 						continue
 					}
 
-					p.Name = strings.TrimSpace(p.Name)
-
-					s := ss[p.Name]
-
-					s.GamesPlayed += 1
-
-					s.Val += p.Val
-
-					s.Points += p.Points
-
-					s.Shots1P += p.Shots1P
-					s.Made1P += p.Made1P
-
-					s.Shots2P += p.Shots2P
-					s.Made2P += p.Made2P
-
-					s.Shots3P += p.Shots3P
-					s.Made3P += p.Made3P
+					if p.Num == "" && p.Name == "TOTALES" {
+						tot.GamesPlayed += 1
+						tot.PlayerStats = tot.PlayerStats.Add(p)
+						continue
+					}
 
-					s.Assists += p.Assists
-					s.Turnovers += p.Turnovers
-					s.Steals += p.Steals
+					// Rows without a usable name aren't linked to any
+					// player, which shouldn't abort the whole report.
+					pl, err := reg.Lookup(defe, p)
+					if errors.Is(err, store.ErrNotFound) {
+						fmt.Fprintf(os.Stderr, "Jugador sin identificar en %s: %v\n", m.Title(), err)
+						continue
+					}
+					dieIf(err)
+					roster[pl.ID] = pl
 
-					s.Fouls += p.Fouls
-					s.Fouled += p.Fouled
+					s := ss[pl.ID]
 
-					s.Rebounds += p.Rebounds
-					s.ReboundsOff += p.ReboundsOff
-					s.ReboundsDef += p.ReboundsDef
+					s.Player = pl.ID
 
-					s.Blocks += p.Blocks
-					s.Blocked += p.Blocked
+					s.GamesPlayed += 1
 
-					s.PlayedMillis += p.PlayedMillis
+					s.PlayerStats = s.PlayerStats.Add(p)
 
-					ss[p.Name] = s
+					ss[pl.ID] = s
 				}
 			}
 		}
@@ -270,18 +287,18 @@ func main() {
 		}
 	}
 
-	data.PlayerStats = ss
+	data.PlayerStats = ss.sorted(roster)
+	data.TeamTotals = tot
 
 	if html {
 		dieIf(writeHTML(data))
 		return
 	}
 
-	ns := make([]string, 0, len(ss))
-	for n := range ss {
-		ns = append(ns, n)
+	rows := data.PlayerStats
+	if tot.GamesPlayed > 0 {
+		rows = append(rows, tot)
 	}
-	sort.Strings(ns)
 
 	fmt.Println()
 
@@ -292,6 +309,7 @@ func main() {
 		wRebs  = tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
 		wBlks  = tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
 		wMins  = tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
+		wAdv   = tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
 	)
 
 	fmt.Fprintln(wShots, "JUGADOR\tPUNTOS\t1P\tTC\t2P\t3P")
@@ -300,9 +318,10 @@ func main() {
 	fmt.Fprintln(wRebs, "JUGADOR\tREBOTES\tOFENSIVOS\tDEFENSIVOS")
 	fmt.Fprintln(wBlks, "JUGADOR\tTAPONES\tRECIBIDOS")
 	fmt.Fprintln(wMins, "JUGADOR\tPARTIDOS\tMINUTOS")
+	fmt.Fprintln(wAdv, "JUGADOR\teFG%\tTS%\tTLI/TC\t3P/TC\tAS/PE\tUSO%\tPTS/40\tREB/40\tAS/40")
 
-	for _, n := range ns {
-		s := ss[n]
+	for _, s := range rows {
+		n := s.Name
 		gp := s.GamesPlayed
 		ms := time.Millisecond * time.Duration(s.PlayedMillis) / time.Minute
 
@@ -341,7 +360,7 @@ func main() {
 			s.Blocked, float32(s.Blocked)/float32(gp),
 		)
 
-		if n == "TOTALES" {
+		if s.Player == 0 {
 			continue
 		}
 
@@ -350,6 +369,15 @@ func main() {
 			gp,
 			float32(int(ms))/float32(gp),
 		)
+
+		fmt.Fprintf(wAdv, "%s\t%5.2f\t%5.2f\t%4.2f\t%4.2f\t%4.2f\t%5.2f\t%5.2f\t%5.2f\t%5.2f\n",
+			n,
+			100*s.EFG(), 100*s.TS(), s.FTRate(), s.ThreePARate(), s.ASTTO(),
+			100*data.Usage(s),
+			s.Per(s.Points, 40*time.Minute),
+			s.Per(s.Rebounds, 40*time.Minute),
+			s.Per(s.Assists, 40*time.Minute),
+		)
 	}
 
 	dieIf(wShots.Flush())
@@ -363,6 +391,34 @@ func main() {
 	dieIf(wBlks.Flush())
 	fmt.Println()
 	dieIf(wMins.Flush())
+	fmt.Println()
+	dieIf(wAdv.Flush())
+	fmt.Println()
+	fmt.Printf("Posesiones: %.1f - Ritmo: %.1f - Ofensiva: %.1f - Defensiva: %.1f\n",
+		data.Totals.Possessions(), data.Pace(), data.OffensiveRating(), data.DefensiveRating())
+
+	fmt.Println()
+	dieIf(writeFactors(data))
+}
+
+func writeFactors(data templateData) error {
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 1, ' ', 0)
+
+	row := func(name string, f analysis.FourFactors) {
+		fmt.Fprintf(w, "%s\t%5.2f\t%5.2f\t%5.2f\t%4.2f\t%5.2f\t%5.2f\t%5.2f\t%4.2f\n",
+			name,
+			100*f.Own.EFG, 100*f.Own.TOV, 100*f.Own.ORB, f.Own.FTR,
+			100*f.Opponent.EFG, 100*f.Opponent.TOV, 100*f.Opponent.ORB, f.Opponent.FTR,
+		)
+	}
+
+	fmt.Fprintln(w, "RIVAL\teFG%\tPÉRD%\tRO%\tTLC/TC\teFG% RIV.\tPÉRD% RIV.\tRO% RIV.\tTLC/TC RIV.")
+	for _, f := range data.MatchFactors() {
+		row(f.Rival, f.FourFactors)
+	}
+	row("TEMPORADA", data.Factors())
+
+	return w.Flush()
 }
 
 func shots(made, total int) string {
@@ -384,16 +440,22 @@ func writeHTML(data templateData) error {
 				return fmt.Sprintf("%5.2f", avg)
 			},
 			"shots": shots,
+			"pct":   func(f float64) string { return fmt.Sprintf("%5.2f", 100*f) },
+			"num":   func(f float64) string { return fmt.Sprintf("%5.2f", f) },
+			"per40": func(s playerStats, v int) string { return fmt.Sprintf("%5.2f", s.Per(v, 40*time.Minute)) },
 
 			"highlight": func(team string, m match) string {
-				if (team == m.HomeTeam && m.HomeScore > m.AwayScore) ||
-					(team == m.AwayTeam && m.AwayScore > m.HomeScore) {
+				if (team == m.HomeTeam && m.HomePoints.Points() > m.AwayPoints.Points()) ||
+					(team == m.AwayTeam && m.AwayPoints.Points() > m.HomePoints.Points()) {
 					return "highlight"
 				}
 				return ""
 			},
 			"matchClass": func(m match) string {
-				if m.HomeScore > m.AwayScore {
+				if !m.Played() {
+					return "pending"
+				}
+				if m.HomePoints.Points() > m.AwayPoints.Points() {
 					return "win-home"
 				}
 				return "win-away"
@@ -0,0 +1,129 @@
+package cabb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// APIError is returned when the CABB API answers with "resultado": "error".
+type APIError struct {
+	Endpoint string
+	Action   string
+	Result   string
+	Message  string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: error response: %s", e.endpoint(), e.Message)
+}
+
+func (e *APIError) endpoint() string {
+	if e.Action == "" {
+		return e.Endpoint
+	}
+	return e.Endpoint + " (" + e.Action + ")"
+}
+
+// SessionExpiredError is an APIError signaling that the key obtained when
+// creating the client is no longer valid.
+type SessionExpiredError struct {
+	APIError
+}
+
+func (e *SessionExpiredError) Error() string {
+	return fmt.Sprintf("%s: session expired: %s", e.endpoint(), e.Message)
+}
+
+func (e *SessionExpiredError) Unwrap() error { return &e.APIError }
+
+// IsNoStats reports whether err is the API refusing to return the stats of a
+// match, e.g. because it wasn't played yet, as opposed to an expired session,
+// a server failure or any other API error.
+func IsNoStats(err error) bool {
+	var (
+		apiErr  *APIError
+		expired *SessionExpiredError
+	)
+	return errors.As(err, &apiErr) && !errors.As(err, &expired) && mentions(apiErr.Message, noStatsWords)
+}
+
+// HTTPStatusError is returned when the server responds with a non 200 status
+// code. RetryAfter holds the value of the Retry-After header, if any.
+type HTTPStatusError struct {
+	Endpoint   string
+	StatusCode int
+	Status     string
+	Body       []byte
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected HTTP status %s", e.Endpoint, e.Status)
+}
+
+// DecodeError is returned when the response body isn't valid JSON. Body holds
+// the beginning of the response.
+type DecodeError struct {
+	Endpoint string
+	Body     []byte
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: decoding response %q: %v", e.Endpoint, e.Body, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+const maxSnippet = 256
+
+func snippet(body []byte) []byte {
+	if len(body) > maxSnippet {
+		body = body[:maxSnippet]
+	}
+	return append([]byte(nil), body...)
+}
+
+// sessionWords are the words of the error messages taken as the key being no
+// longer valid. There's no captured response of an expired session to match
+// exactly, so any error mentioning the key or the session is one: renewing the
+// key and sending the request again once is cheap if it wasn't.
+var sessionWords = []string{"key", "sesion", "sesión"}
+
+func sessionExpired(msg string) bool { return mentions(msg, sessionWords) }
+
+// noStatsWords are the words of the error messages taken as a match without
+// stats, like "no hay estadísticas para el partido". There's no captured
+// response of one either, so other errors are taken as failures to retry.
+var noStatsWords = []string{"estadistica", "estadisticas", "estadística", "estadísticas"}
+
+// mentions reports whether msg has any of the words, ignoring case.
+func mentions(msg string, words []string) bool {
+	fields := strings.FieldsFunc(strings.ToLower(msg), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, f := range fields {
+		for _, w := range words {
+			if f == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newAPIError(endpoint, action string, r cabbResponseGeneric) error {
+	e := APIError{
+		Endpoint: endpoint,
+		Action:   action,
+		Result:   r.Result,
+		Message:  r.Error,
+	}
+
+	if sessionExpired(r.Error) {
+		return &SessionExpiredError{e}
+	}
+
+	return &e
+}
@@ -0,0 +1,68 @@
+package cabb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		msg     string
+		expired bool
+	}{
+		{"key no válida", true},
+		{"Key no válida ", true},
+		{"KEY caducada", true},
+		{"falta el parámetro key", true},
+		{"la sesión expiró", true},
+		{"no hay estadísticas para el partido", false},
+		{"token de notificación inválido", false},
+		{"la clave del equipo no existe", false},
+		{"keyword desconocida", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		err := newAPIError("envivo/partido.ashx", "", cabbResponseGeneric{Result: "error", Error: tt.msg})
+
+		var expired *SessionExpiredError
+		if got := errors.As(err, &expired); got != tt.expired {
+			t.Errorf("%q: expired = %v, want %v", tt.msg, got, tt.expired)
+		}
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Message != tt.msg {
+			t.Errorf("%q: got %#v, want an APIError with the message", tt.msg, err)
+		}
+	}
+}
+
+func TestIsNoStats(t *testing.T) {
+	apiErr := func(msg string) error {
+		return newAPIError("estadisticas.ashx", "", cabbResponseGeneric{Result: "error", Error: msg})
+	}
+	noStats := apiErr("no hay estadísticas para el partido")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"api error", noStats, true},
+		{"wrapped", fmt.Errorf("stats of M01: %w", noStats), true},
+		{"without accents", apiErr("No hay estadisticas"), true},
+		{"session expired", apiErr("key no válida"), false},
+		{"other api error", apiErr("partido no encontrado"), false},
+		{"server busy", apiErr("servicio no disponible"), false},
+		{"http status", &HTTPStatusError{StatusCode: 500}, false},
+		{"other", errors.New("connection refused"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNoStats(tt.err); got != tt.want {
+			t.Errorf("%s: IsNoStats() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package cabb
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Score is the points scored by a team in a match. The zero value is the
+// score of a match without a result yet, so a Match that wasn't decoded from
+// the API isn't mistaken for a 0 - 0.
+type Score struct {
+	points int
+	played bool
+}
+
+// NotPlayed is the Score of a team in a match without a result yet.
+var NotPlayed = Score{}
+
+// NewScore returns the score of a team that scored points.
+func NewScore(points int) Score { return Score{points: points, played: true} }
+
+func (s Score) Played() bool { return s.played }
+
+// Points returns the points scored, 0 if the match wasn't played.
+func (s Score) Points() int { return s.points }
+
+func (s Score) String() string {
+	if !s.Played() {
+		return ""
+	}
+	return strconv.Itoa(s.points)
+}
+
+func parseScore(s string) Score {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return NotPlayed
+	}
+	return NewScore(n)
+}
+
+type MatchStatus int
+
+const (
+	StatusUnknown MatchStatus = iota
+	StatusScheduled
+	StatusLive
+	StatusFinished
+	StatusSuspended
+	StatusPostponed
+	StatusForfeit
+)
+
+func (s MatchStatus) String() string {
+	switch s {
+	case StatusScheduled:
+		return "scheduled"
+	case StatusLive:
+		return "live"
+	case StatusFinished:
+		return "finished"
+	case StatusSuspended:
+		return "suspended"
+	case StatusPostponed:
+		return "postponed"
+	case StatusForfeit:
+		return "forfeit"
+	}
+	return "unknown"
+}
+
+var accents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n")
+
+// ParseMatchStatus maps the estado reported by the API to a MatchStatus.
+// Negative and scheduled forms are checked first, as "no jugado" or "a
+// jugarse" contain the words of a finished match.
+func ParseMatchStatus(raw string) MatchStatus {
+	s := accents.Replace(strings.ToLower(strings.TrimSpace(raw)))
+
+	switch {
+	case s == "":
+		return StatusUnknown
+	case strings.Contains(s, "incomparec"), strings.Contains(s, "no presentado"), strings.Contains(s, "w.o"):
+		return StatusForfeit
+	case strings.Contains(s, "suspend"):
+		return StatusSuspended
+	case strings.Contains(s, "aplaz"), strings.Contains(s, "postergado"), strings.Contains(s, "reprogram"):
+		return StatusPostponed
+	case strings.Contains(s, "no jugado"), strings.Contains(s, "sin jugar"), strings.Contains(s, "a jugar"),
+		strings.Contains(s, "no disputado"), strings.Contains(s, "no iniciado"),
+		strings.Contains(s, "pendiente"), strings.Contains(s, "program"):
+		return StatusScheduled
+	case strings.Contains(s, "final"), strings.Contains(s, "jugado"), strings.Contains(s, "terminado"):
+		return StatusFinished
+	case strings.Contains(s, "en juego"), strings.Contains(s, "vivo"), strings.Contains(s, "curso"):
+		return StatusLive
+	}
+
+	return StatusUnknown
+}
+
+// Argentina is the time zone of match kickoffs.
+var Argentina = func() *time.Location {
+	if loc, err := time.LoadLocation("America/Argentina/Buenos_Aires"); err == nil {
+		return loc
+	}
+	return time.FixedZone("ART", -3*60*60)
+}()
+
+var (
+	dateLayouts = []string{"02/01/2006", "2/1/2006", "2006-01-02"}
+	timeLayouts = []string{"15:04:05", "15:04"}
+)
+
+func parseKickoff(date, hour string) time.Time {
+	date, hour = strings.TrimSpace(date), strings.TrimSpace(hour)
+
+	for _, dl := range dateLayouts {
+		d, err := time.ParseInLocation(dl, date, Argentina)
+		if err != nil {
+			continue
+		}
+
+		for _, tl := range timeLayouts {
+			if t, err := time.ParseInLocation(dl+" "+tl, date+" "+hour, Argentina); err == nil {
+				return t
+			}
+		}
+
+		return d
+	}
+
+	return time.Time{}
+}
+
+// UnmarshalJSON decodes the match as sent by the API, keeping the raw values
+// and filling the parsed HomePoints, AwayPoints, Kickoff and State.
+func (m *Match) UnmarshalJSON(b []byte) error {
+	type match Match
+	if err := json.Unmarshal(b, (*match)(m)); err != nil {
+		return err
+	}
+
+	m.Parse()
+
+	return nil
+}
+
+// Parse fills HomePoints, AwayPoints, Kickoff and State from the raw values,
+// e.g. for matches loaded from a database instead of the API.
+func (m *Match) Parse() {
+	m.HomePoints = parseScore(m.HomeScore)
+	m.AwayPoints = parseScore(m.AwayScore)
+	m.Kickoff = parseKickoff(m.Date, m.Time)
+	m.State = ParseMatchStatus(m.Status)
+}
+
+// Played reports whether both teams have a score.
+func (m Match) Played() bool {
+	return m.HomePoints.Played() && m.AwayPoints.Played()
+}
+
+// Bye is the name of the rival of a team that doesn't play in a game day.
+const Bye = "LIBRE"
+
+// IsBye reports whether name is the placeholder rival of a team that doesn't
+// play in a game day rather than a real team.
+func IsBye(name string) bool {
+	return strings.EqualFold(strings.TrimSpace(name), Bye)
+}
+
+// IsBye reports whether m is a game day a team doesn't play.
+func (m Match) IsBye() bool {
+	return IsBye(m.HomeTeam) || IsBye(m.AwayTeam)
+}
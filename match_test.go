@@ -0,0 +1,79 @@
+package cabb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMatchStatus(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want MatchStatus
+	}{
+		{"", StatusUnknown},
+		{"Finalizado", StatusFinished},
+		{"Jugado", StatusFinished},
+		{"TERMINADO", StatusFinished},
+		{"No jugado", StatusScheduled},
+		{"Sin jugar", StatusScheduled},
+		{"A jugarse", StatusScheduled},
+		{"No disputado", StatusScheduled},
+		{"Pendiente", StatusScheduled},
+		{"Programado", StatusScheduled},
+		{"En juego", StatusLive},
+		{"En vivo", StatusLive},
+		{"En curso", StatusLive},
+		{"Suspendido", StatusSuspended},
+		{"Aplazado", StatusPostponed},
+		{"Postergado", StatusPostponed},
+		{"Reprogramado", StatusPostponed},
+		{"Incomparecencia", StatusForfeit},
+		{"W.O.", StatusForfeit},
+		{"cualquier cosa", StatusUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMatchStatus(tt.raw); got != tt.want {
+			t.Errorf("ParseMatchStatus(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestMatchPlayed(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		played     bool
+		home, away int
+	}{
+		{"result", `{"puntosEquipo1":"64","puntosEquipo2":"60"}`, true, 64, 60},
+		{"zero", `{"puntosEquipo1":"0","puntosEquipo2":"20"}`, true, 0, 20},
+		{"empty", `{"puntosEquipo1":"","puntosEquipo2":""}`, false, 0, 0},
+		{"dash", `{"puntosEquipo1":"-","puntosEquipo2":"-"}`, false, 0, 0},
+		{"one side", `{"puntosEquipo1":"10","puntosEquipo2":""}`, false, 10, 0},
+	}
+
+	for _, tt := range tests {
+		var m Match
+		if err := json.Unmarshal([]byte(tt.json), &m); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := m.Played(); got != tt.played {
+			t.Errorf("%s: Played() = %v, want %v", tt.name, got, tt.played)
+		}
+		if m.HomePoints.Points() != tt.home || m.AwayPoints.Points() != tt.away {
+			t.Errorf("%s: points = %d - %d, want %d - %d", tt.name,
+				m.HomePoints.Points(), m.AwayPoints.Points(), tt.home, tt.away)
+		}
+	}
+
+	if (Match{}).Played() {
+		t.Error("zero Match is played")
+	}
+
+	m := Match{HomeScore: "70", AwayScore: "71", Status: "Finalizado"}
+	m.Parse()
+	if !m.Played() || m.State != StatusFinished || m.AwayPoints != NewScore(71) {
+		t.Errorf("Parse() = %+v", m)
+	}
+}
@@ -0,0 +1,194 @@
+package cabb
+
+import (
+	"strings"
+	"time"
+)
+
+// Ratio returns n/d, or 0 when d is 0, e.g. for stats of players that didn't
+// take any shot.
+func Ratio(n, d float64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+func (p PlayerStats) FGMade() int  { return p.Made2P + p.Made3P }
+func (p PlayerStats) FGShots() int { return p.Shots2P + p.Shots3P }
+
+// EFG is the effective field goal percentage, which weights 3 pointers.
+func (p PlayerStats) EFG() float64 {
+	return Ratio(float64(p.FGMade())+0.5*float64(p.Made3P), float64(p.FGShots()))
+}
+
+// TS is the true shooting percentage, accounting for free throws.
+func (p PlayerStats) TS() float64 {
+	return Ratio(float64(p.Points), 2*(float64(p.FGShots())+0.44*float64(p.Shots1P)))
+}
+
+// FTRate is the number of free throws attempted per field goal attempted.
+func (p PlayerStats) FTRate() float64 {
+	return Ratio(float64(p.Shots1P), float64(p.FGShots()))
+}
+
+// FTMRate is the number of free throws made per field goal attempted, the
+// free throw factor of Dean Oliver's Four Factors.
+func (p PlayerStats) FTMRate() float64 {
+	return Ratio(float64(p.Made1P), float64(p.FGShots()))
+}
+
+// ThreePARate is the share of field goals attempted from 3 point range.
+func (p PlayerStats) ThreePARate() float64 {
+	return Ratio(float64(p.Shots3P), float64(p.FGShots()))
+}
+
+// ASTTO is the number of assists per turnover.
+func (p PlayerStats) ASTTO() float64 {
+	return Ratio(float64(p.Assists), float64(p.Turnovers))
+}
+
+// TOVRate is the share of the possessions used that ended in a turnover.
+func (p PlayerStats) TOVRate() float64 {
+	tov := float64(p.Turnovers)
+	return Ratio(tov, float64(p.FGShots())+0.44*float64(p.Shots1P)+tov)
+}
+
+// Possessions estimates the possessions used, usually of a whole team.
+func (p PlayerStats) Possessions() float64 {
+	return float64(p.FGShots()) + 0.44*float64(p.Shots1P) - float64(p.ReboundsOff) + float64(p.Turnovers)
+}
+
+// Usage is the share of the team possessions used by the player while on
+// court. team are the team totals, including the minutes played by all of its
+// players.
+func (p PlayerStats) Usage(team PlayerStats) float64 {
+	used := func(s PlayerStats) float64 {
+		return float64(s.FGShots()) + 0.44*float64(s.Shots1P) + float64(s.Turnovers)
+	}
+
+	mins, teamMins := p.PlayedDuration().Minutes(), team.PlayedDuration().Minutes()
+
+	return Ratio(used(p)*teamMins/5, mins*used(team))
+}
+
+// Per normalizes v to the given time on court, e.g. p.Per(p.Points,
+// 40*time.Minute) returns the points per 40 minutes.
+func (p PlayerStats) Per(v int, d time.Duration) float64 {
+	return Ratio(float64(v)*float64(d), float64(p.PlayedDuration()))
+}
+
+// IsTotals reports whether p is the TOTALES row of a team box score.
+func (p PlayerStats) IsTotals() bool {
+	return p.Num == "" && strings.TrimSpace(p.Name) == "TOTALES"
+}
+
+// Totals returns the TOTALES row of a team box score, or the sum of every
+// player if there's none. The API doesn't report the minutes of the team, so
+// they're always the sum of the minutes of its players.
+func Totals(ps []PlayerStats) PlayerStats {
+	var (
+		t, row PlayerStats
+		found  bool
+		played time.Duration
+	)
+
+	for _, p := range ps {
+		if p.IsTotals() {
+			row, found = p, true
+			continue
+		}
+
+		t = t.Add(p)
+		played += p.PlayedDuration()
+	}
+
+	if found {
+		t = row
+	}
+
+	t.Name = "TOTALES"
+	t.PlayedMillis = played.Milliseconds()
+	t.Played = ""
+
+	return t
+}
+
+// Add returns the sum of the counting stats of p and o.
+func (p PlayerStats) Add(o PlayerStats) PlayerStats {
+	p.Val += o.Val
+	p.Points += o.Points
+	p.Shots1P += o.Shots1P
+	p.Made1P += o.Made1P
+	p.Missed1p += o.Missed1p
+	p.Shots2P += o.Shots2P
+	p.Made2P += o.Made2P
+	p.Missed2p += o.Missed2p
+	p.Shots3P += o.Shots3P
+	p.Made3P += o.Made3P
+	p.Missed3p += o.Missed3p
+	p.Assists += o.Assists
+	p.Turnovers += o.Turnovers
+	p.Steals += o.Steals
+	p.Fouls += o.Fouls
+	p.Fouled += o.Fouled
+	p.Rebounds += o.Rebounds
+	p.ReboundsOff += o.ReboundsOff
+	p.ReboundsDef += o.ReboundsDef
+	p.Blocks += o.Blocks
+	p.Blocked += o.Blocked
+	p.PlayedMillis += o.PlayedMillis
+	p.Played = ""
+	return p
+}
+
+// Duration returns the length of the match, including overtimes, using the
+// FIBA clock rules.
+func (m LiveMatch) Duration() time.Duration {
+	r := FIBA.ForMatch(m)
+
+	n := len(m.Periods)
+	if n < r.Periods {
+		n = r.Periods
+	}
+
+	var d time.Duration
+	for p := 1; p <= n; p++ {
+		d += r.Length(p)
+	}
+
+	return d
+}
+
+func (s Stats) HomeTotals() PlayerStats { return Totals(s.Stats.Home) }
+func (s Stats) AwayTotals() PlayerStats { return Totals(s.Stats.Away) }
+
+// Possessions estimates the possessions of each team in the match, as the
+// average of both teams estimations.
+func (s Stats) Possessions() float64 {
+	return (s.HomeTotals().Possessions() + s.AwayTotals().Possessions()) / 2
+}
+
+// Pace is the number of possessions per 40 minutes.
+func (s Stats) Pace() float64 {
+	return Ratio(s.Possessions()*40, s.Match.Duration().Minutes())
+}
+
+// OffensiveRating returns the points scored per 100 possessions by the home
+// and away teams. The defensive rating of a team is the offensive rating of
+// its opponent.
+func (s Stats) OffensiveRating() (home, away float64) {
+	poss := s.Possessions()
+	return Ratio(100*float64(s.HomeTotals().Points), poss), Ratio(100*float64(s.AwayTotals().Points), poss)
+}
+
+// OffensiveRating returns the points scored by team per 100 possessions,
+// estimating them from both team totals.
+func OffensiveRating(team, opp PlayerStats) float64 {
+	return Ratio(100*float64(team.Points), (team.Possessions()+opp.Possessions())/2)
+}
+
+// DefensiveRating returns the points allowed by team per 100 possessions.
+func DefensiveRating(team, opp PlayerStats) float64 {
+	return OffensiveRating(opp, team)
+}
@@ -0,0 +1,62 @@
+package cabb
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTotals(t *testing.T) {
+	ms := func(d time.Duration) int64 { return d.Milliseconds() }
+
+	players := []PlayerStats{
+		{Num: "4", Name: "PEREZ", Points: 10, Shots2P: 8, PlayedMillis: ms(30 * time.Minute)},
+		{Num: "5", Name: "GOMEZ", Points: 4, Shots2P: 4, Played: "10:00"},
+	}
+
+	tests := []struct {
+		name   string
+		ps     []PlayerStats
+		points int
+	}{
+		{"sum", players, 14},
+		{"row without minutes", append(players, PlayerStats{Name: "TOTALES", Points: 16}), 16},
+		{"row with spaces", append(players, PlayerStats{Name: " TOTALES ", Points: 16, PlayedMillis: 1}), 16},
+	}
+
+	for _, tt := range tests {
+		got := Totals(tt.ps)
+		if got.Name != "TOTALES" || got.Points != tt.points {
+			t.Errorf("%s: Totals() = %s %d points, want TOTALES %d", tt.name, got.Name, got.Points, tt.points)
+		}
+		if d := got.PlayedDuration(); d != 40*time.Minute {
+			t.Errorf("%s: team minutes = %v, want 40m", tt.name, d)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	// Two players of the same team, each using the same possessions per
+	// minute, have the same usage, which is 20% when on court with four
+	// teammates that use as many possessions.
+	p := PlayerStats{Shots2P: 10, PlayedMillis: (20 * time.Minute).Milliseconds()}
+	team := Totals([]PlayerStats{p, p, p, p, p, p, p, p, p, p})
+
+	if got := p.Usage(team); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("Usage() = %v, want 0.2", got)
+	}
+
+	if got := (PlayerStats{}).Usage(team); got != 0 {
+		t.Errorf("Usage() without minutes = %v, want 0", got)
+	}
+}
+
+func TestPer(t *testing.T) {
+	p := PlayerStats{Points: 10, PlayedMillis: (20 * time.Minute).Milliseconds()}
+	if got := p.Per(p.Points, 40*time.Minute); got != 20 {
+		t.Errorf("Per() = %v, want 20", got)
+	}
+	if got := (PlayerStats{Points: 3}).Per(3, 40*time.Minute); got != 0 {
+		t.Errorf("Per() without minutes = %v, want 0", got)
+	}
+}
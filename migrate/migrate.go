@@ -0,0 +1,242 @@
+// Package migrate applies versioned schema migrations to sqlite databases.
+//
+// Migrations are SQL files named NNNN_name.sql, usually embedded in the
+// package owning the database. The version of a database is tracked in its
+// schema_version table.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is a forward schema change.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func (m Migration) String() string { return fmt.Sprintf("%04d_%s", m.Version, m.Name) }
+
+// Applied is a migration applied to a database.
+type Applied struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Status is the migration state of a database.
+type Status struct {
+	Current int
+	Applied []Applied
+	Pending []Migration
+}
+
+const versionSchema = `
+CREATE TABLE IF NOT EXISTS schema_version (
+       version INTEGER PRIMARY KEY,
+       name TEXT NOT NULL,
+       applied_at TEXT NOT NULL
+);
+`
+
+// Load reads the migrations in dir of fsys, sorted by version.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	var ms []Migration
+
+	seen := make(map[int]string)
+
+	for _, f := range entries {
+		if f.IsDir() || path.Ext(f.Name()) != ".sql" {
+			continue
+		}
+
+		base := strings.TrimSuffix(f.Name(), ".sql")
+		num, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration name %s", f.Name())
+		}
+
+		v, err := strconv.Atoi(num)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid migration version %s", f.Name())
+		}
+
+		if prev, ok := seen[v]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", v, prev, f.Name())
+		}
+		seen[v] = f.Name()
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", f.Name(), err)
+		}
+
+		ms = append(ms, Migration{Version: v, Name: name, SQL: string(b)})
+	}
+
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+
+	return ms, nil
+}
+
+// tracked reports whether the database has a schema_version table.
+func tracked(db *sql.DB) (bool, error) {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'").Scan(&n)
+	return n > 0, err
+}
+
+// Version returns the current version of the database, 0 if no migration
+// was applied. It doesn't write to the database.
+func Version(db *sql.DB) (int, error) {
+	ok, err := tracked(db)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	var v sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&v); err != nil {
+		return 0, err
+	}
+
+	return int(v.Int64), nil
+}
+
+// GetStatus returns the applied and pending migrations of the database. It
+// doesn't write to the database, so it can be used on read-only connections.
+func GetStatus(db *sql.DB, ms []Migration) (Status, error) {
+	var s Status
+
+	v, err := Version(db)
+	if err != nil {
+		return s, err
+	}
+	s.Current = v
+
+	if v == 0 {
+		s.Pending = ms
+		return s, nil
+	}
+
+	rows, err := db.Query("SELECT version, name, applied_at FROM schema_version ORDER BY version")
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			a  Applied
+			at string
+		)
+		if err := rows.Scan(&a.Version, &a.Name, &at); err != nil {
+			return s, err
+		}
+		a.AppliedAt, _ = time.Parse(time.RFC3339, at)
+		s.Applied = append(s.Applied, a)
+	}
+	if err := rows.Err(); err != nil {
+		return s, err
+	}
+
+	for _, m := range ms {
+		if m.Version > v {
+			s.Pending = append(s.Pending, m)
+		}
+	}
+
+	return s, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the ones applied.
+func Up(db *sql.DB, ms []Migration) ([]Migration, error) {
+	if _, err := db.Exec(versionSchema); err != nil {
+		return nil, err
+	}
+
+	s, err := GetStatus(db, ms)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+
+	for _, m := range s.Pending {
+		if err := apply(db, m); err != nil {
+			return done, fmt.Errorf("applying migration %s: %w", m, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func apply(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := record(tx, m); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func record(tx *sql.Tx, m Migration) error {
+	_, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)",
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// Baseline marks the migrations up to version as applied without running
+// them, for databases created before migrations were tracked. It does nothing
+// if any migration was already applied.
+func Baseline(db *sql.DB, ms []Migration, version int) error {
+	v, err := Version(db)
+	if err != nil || v > 0 {
+		return err
+	}
+
+	if _, err := db.Exec(versionSchema); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range ms {
+		if m.Version > version {
+			break
+		}
+		if err := record(tx, m); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,195 @@
+package migrate
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var migrations = fstest.MapFS{
+	"m/0002_index.sql": {Data: []byte("CREATE INDEX teams_name ON teams (name);")},
+	"m/0001_teams.sql": {Data: []byte("CREATE TABLE teams (id TEXT PRIMARY KEY, name TEXT);")},
+	"m/README.md":      {Data: []byte("not a migration")},
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		fs   fstest.MapFS
+		want []string
+		err  bool
+	}{
+		{"sorted", migrations, []string{"0001_teams", "0002_index"}, false},
+		{"no name", fstest.MapFS{"m/0001.sql": {}}, nil, true},
+		{"no version", fstest.MapFS{"m/teams_x.sql": {}}, nil, true},
+		{"zero version", fstest.MapFS{"m/0000_teams.sql": {}}, nil, true},
+		{"duplicate", fstest.MapFS{"m/0001_a.sql": {}, "m/1_b.sql": {}}, nil, true},
+		{"missing dir", fstest.MapFS{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		ms, err := Load(tt.fs, "m")
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+
+		var got []string
+		for _, m := range ms {
+			got = append(got, m.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Load() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func open(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	var ts []string
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		ts = append(ts, n)
+	}
+	return ts
+}
+
+func TestUpAndStatus(t *testing.T) {
+	ms, err := Load(migrations, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := open(t, path)
+
+	s, err := GetStatus(db, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Current != 0 || len(s.Applied) != 0 || len(s.Pending) != 2 {
+		t.Errorf("status before up = %+v", s)
+	}
+	if ts := tables(t, db); len(ts) != 0 {
+		t.Errorf("status wrote tables %v", ts)
+	}
+
+	done, err := Up(db, ms[:1])
+	if err != nil || len(done) != 1 {
+		t.Fatalf("Up() = %v, %v", done, err)
+	}
+
+	// A read-only connection is enough for the status.
+	ro := open(t, "file:"+path+"?mode=ro")
+
+	s, err = GetStatus(ro, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Current != 1 || len(s.Applied) != 1 || s.Applied[0].Name != "teams" || s.Applied[0].AppliedAt.IsZero() ||
+		len(s.Pending) != 1 || s.Pending[0].Version != 2 {
+		t.Errorf("status after first up = %+v", s)
+	}
+
+	done, err = Up(db, ms)
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("Up() = %v, %v", done, err)
+	}
+
+	done, err = Up(db, ms)
+	if err != nil || len(done) != 0 {
+		t.Errorf("Up() when up to date = %v, %v", done, err)
+	}
+
+	if v, err := Version(ro); err != nil || v != 2 {
+		t.Errorf("Version() = %d, %v, want 2", v, err)
+	}
+
+	if want := []string{"schema_version", "teams"}; !reflect.DeepEqual(tables(t, db), want) {
+		t.Errorf("tables = %v, want %v", tables(t, db), want)
+	}
+}
+
+func TestUpFailure(t *testing.T) {
+	ms := []Migration{
+		{Version: 1, Name: "ok", SQL: "CREATE TABLE a (id INTEGER);"},
+		{Version: 2, Name: "broken", SQL: "CREATE TABLE a (id INTEGER);"},
+	}
+
+	db := open(t, filepath.Join(t.TempDir(), "test.db"))
+
+	done, err := Up(db, ms)
+	if err == nil || len(done) != 1 {
+		t.Fatalf("Up() = %v, %v, want the first migration and an error", done, err)
+	}
+
+	if v, err := Version(db); err != nil || v != 1 {
+		t.Errorf("Version() = %d, %v, want 1", v, err)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	ms, err := Load(migrations, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := open(t, filepath.Join(t.TempDir(), "test.db"))
+
+	// The schema of the first migration, created before they were tracked.
+	if _, err := db.Exec(ms[0].SQL); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Baseline(db, ms, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := Up(db, ms)
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("Up() after baseline = %v, %v", done, err)
+	}
+
+	// Baseline does nothing once migrations are tracked.
+	if err := Baseline(db, ms, 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := Version(db); v != 2 {
+		t.Errorf("Version() = %d, want 2", v)
+	}
+}
+
+func TestStatusMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.db")
+	db := open(t, "file:"+path+"?mode=ro")
+
+	if _, err := GetStatus(db, nil); err == nil {
+		t.Error("GetStatus() of a missing read-only database succeeded")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("database created: %v", err)
+	}
+}
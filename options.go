@@ -0,0 +1,33 @@
+package cabb
+
+import "net/http"
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to perform requests. Defaults to
+// http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+// WithBaseURL points the client to a different CABB API server, like a local
+// fake or an httptest.Server.
+func WithBaseURL(u string) Option {
+	return func(c *Client) { c.baseURL = u }
+}
+
+// WithUserAgent sets the User-Agent header sent on every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithAppVersion sets the application version reported when establishing the
+// connection.
+func WithAppVersion(v string) Option {
+	return func(c *Client) { c.version = v }
+}
@@ -0,0 +1,159 @@
+package players
+
+import (
+	"sort"
+	"strings"
+)
+
+// MinSimilarity is the similarity of two names above which their players are
+// suggested as the same.
+const MinSimilarity = 0.8
+
+// Similarity returns how alike two names are, between 0 and 1, ignoring the
+// order of their words, e.g. "FERNANDEZ JUAN" and "JUAN FERNANDES" are 0.93.
+func Similarity(a, b string) float64 {
+	a, b = Normalize(a), Normalize(b)
+	if a == "" || b == "" {
+		return 0
+	}
+
+	s := similarity(a, b)
+	if t := similarity(sortWords(a), sortWords(b)); t > s {
+		s = t
+	}
+	return s
+}
+
+func sortWords(s string) string {
+	ws := strings.Fields(s)
+	sort.Strings(ws)
+	return strings.Join(ws, " ")
+}
+
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+
+	n := len(ra)
+	if len(rb) > n {
+		n = len(rb)
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(n)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minOf(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minOf(vs ...int) int {
+	m := vs[0]
+	for _, v := range vs[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Suggestion is a pair of players of a team that might be the same.
+type Suggestion struct {
+	A, B  Player
+	Score float64
+}
+
+// Suggestions returns the pairs of players of a team with similar names that
+// never played the same match and weren't marked as distinct, most similar
+// first.
+func (r *Registry) Suggestions(team string) ([]Suggestion, error) {
+	ps, err := r.Players(team)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, ids, err := r.appearances(team)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[int64]map[string]bool)
+	for i, rw := range rows {
+		if matches[ids[i]] == nil {
+			matches[ids[i]] = make(map[string]bool)
+		}
+		matches[ids[i]][rw.MatchID] = true
+	}
+
+	together := func(a, b int64) bool {
+		for m := range matches[a] {
+			if matches[b][m] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var ss []Suggestion
+
+	for i, a := range ps {
+		for _, b := range ps[i+1:] {
+			score := names(a, b)
+			if score < MinSimilarity || together(a.ID, b.ID) {
+				continue
+			}
+
+			d, err := distinct(r.db, a.ID, b.ID)
+			if err != nil {
+				return nil, err
+			}
+			if d {
+				continue
+			}
+
+			ss = append(ss, Suggestion{A: a, B: b, Score: score})
+		}
+	}
+
+	sort.SliceStable(ss, func(i, j int) bool { return ss[i].Score > ss[j].Score })
+
+	return ss, nil
+}
+
+// names returns the highest similarity between the names of two players.
+func names(a, b Player) float64 {
+	na, nb := []string{a.Name}, []string{b.Name}
+	for _, k := range a.Keys {
+		na = append(na, k.Name)
+	}
+	for _, k := range b.Keys {
+		nb = append(nb, k.Name)
+	}
+
+	var s float64
+	for _, x := range na {
+		for _, y := range nb {
+			if v := Similarity(x, y); v > s {
+				s = v
+			}
+		}
+	}
+	return s
+}
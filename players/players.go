@@ -0,0 +1,681 @@
+// Package players resolves the players of box scores and play-by-play into
+// stable IDs per team, so their stats can be aggregated across matches and
+// seasons even if their shirt number or the spelling of their name changes, or
+// two of them share a name.
+//
+// Box score rows are linked by team, number and normalized name, and actions
+// by their ActorID. Links are made automatically when indexing matches, and
+// can be overridden with Merge, Split and Distinct.
+package players
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/store"
+	"github.com/jmoiron/sqlx"
+)
+
+// Key is a box score row of a player, with a normalized name.
+type Key struct {
+	Num  string `db:"num"`
+	Name string `db:"name"`
+}
+
+// Player is a player of a team.
+type Player struct {
+	ID   int64  `db:"id"`
+	Team string `db:"team"`
+	Name string `db:"name"`
+
+	Keys   []Key
+	Actors []string
+}
+
+// Nums returns the shirt numbers worn by the player.
+func (p Player) Nums() []string {
+	var ns []string
+	seen := make(map[string]bool)
+	for _, k := range p.Keys {
+		if !seen[k.Num] {
+			seen[k.Num] = true
+			ns = append(ns, k.Num)
+		}
+	}
+	return ns
+}
+
+var accents = strings.NewReplacer(
+	"Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ü", "U", "Ñ", "N",
+	".", " ", ",", " ", "'", "", "´", "",
+)
+
+// Normalize returns the name used to match players: upper case, without
+// accents, punctuation or repeated spaces.
+func Normalize(name string) string {
+	return strings.Join(strings.Fields(accents.Replace(strings.ToUpper(name))), " ")
+}
+
+func team(t string) string { return strings.TrimSpace(t) }
+
+// isPlayer reports whether p is a player and not the team totals.
+func isPlayer(p cabb.PlayerStats) bool {
+	return Normalize(p.Name) != "" && !p.IsTotals()
+}
+
+// Registry links box scores and play-by-play to players. It's stored in the
+// database of the store.
+type Registry struct {
+	store *store.Store
+	db    *sqlx.DB
+}
+
+// New returns the registry of the store.
+func New(s *store.Store) *Registry {
+	return &Registry{store: s, db: s.DB()}
+}
+
+func (r *Registry) tx(f func(*sqlx.Tx) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func keyID(tx *sqlx.Tx, team string, k Key) (int64, bool, error) {
+	var id int64
+	err := tx.Get(&id, "SELECT player_id FROM player_keys WHERE team = ? AND num = ? AND name = ?", team, k.Num, k.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return id, err == nil, err
+}
+
+func newPlayer(tx *sqlx.Tx, team, name string) (int64, error) {
+	res, err := tx.Exec("INSERT INTO players (team, name) VALUES (?, ?)", team, strings.Join(strings.Fields(name), " "))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// IndexStats links the players of both teams of a box score, creating the
+// players seen for the first time.
+func (r *Registry) IndexStats(st cabb.Stats) error {
+	return r.tx(func(tx *sqlx.Tx) error {
+		if err := index(tx, team(st.Match.Home), st.Stats.Home); err != nil {
+			return fmt.Errorf("indexing %s in %s: %w", st.Match.Home, st.MatchID, err)
+		}
+		if err := index(tx, team(st.Match.Away), st.Stats.Away); err != nil {
+			return fmt.Errorf("indexing %s in %s: %w", st.Match.Away, st.MatchID, err)
+		}
+		return nil
+	})
+}
+
+// index links the box score of a team. A new (num, name) belongs to the only
+// player with that name not already in the box score, which catches number
+// changes; otherwise, e.g. two players sharing a name, it's a new player.
+// Spelling variations are left to Suggestions.
+func index(tx *sqlx.Tx, team string, ps []cabb.PlayerStats) error {
+	var (
+		used    = make(map[int64]bool)
+		pending []cabb.PlayerStats
+	)
+
+	for _, p := range ps {
+		if !isPlayer(p) {
+			continue
+		}
+
+		id, ok, err := keyID(tx, team, Key{p.Num, Normalize(p.Name)})
+		if err != nil {
+			return err
+		}
+		if ok {
+			used[id] = true
+		} else {
+			pending = append(pending, p)
+		}
+	}
+
+	for _, p := range pending {
+		k := Key{p.Num, Normalize(p.Name)}
+
+		var ids []int64
+		if err := tx.Select(&ids, "SELECT DISTINCT player_id FROM player_keys WHERE team = ? AND name = ?", team, k.Name); err != nil {
+			return err
+		}
+
+		var free []int64
+		for _, id := range ids {
+			if !used[id] {
+				free = append(free, id)
+			}
+		}
+
+		var id int64
+		if len(free) == 1 {
+			id = free[0]
+		} else {
+			var err error
+			if id, err = newPlayer(tx, team, p.Name); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec("INSERT INTO player_keys (team, num, name, player_id) VALUES (?, ?, ?, ?)", team, k.Num, k.Name, id)
+		if err != nil {
+			return fmt.Errorf("linking %s #%s: %w", k.Name, k.Num, err)
+		}
+
+		used[id] = true
+	}
+
+	return nil
+}
+
+// row is a box score row of the store.
+type row struct {
+	MatchID string `db:"match_id"`
+	Home    bool   `db:"home"`
+	Team    string `db:"team"`
+	cabb.PlayerStats
+}
+
+func (r row) key() Key { return Key{r.Num, Normalize(r.Name)} }
+
+// keys returns the player of every key of a team.
+func keys(q sqlx.Queryer, team string) (map[Key]int64, error) {
+	var ks []struct {
+		Key
+		PlayerID int64 `db:"player_id"`
+	}
+	if err := sqlx.Select(q, &ks, "SELECT num, name, player_id FROM player_keys WHERE team = ?", team); err != nil {
+		return nil, err
+	}
+
+	m := make(map[Key]int64, len(ks))
+	for _, k := range ks {
+		m[k.Key] = k.PlayerID
+	}
+	return m, nil
+}
+
+// IndexLive links the ActorID of the actions of a match to the players of its
+// box score, which must be in the store and already indexed. An ActorID seen
+// before identifies the same player, so a box score row linked to another
+// player is moved to it, unless it's an override or they are distinct.
+func (r *Registry) IndexLive(l cabb.Live) error {
+	id := l.Match.MatchID
+	if id == "" {
+		return errors.New("indexing play-by-play: missing match ID")
+	}
+
+	return r.tx(func(tx *sqlx.Tx) error {
+		var rows []row
+		if err := tx.Select(&rows, "SELECT * FROM player_stats WHERE match_id = ?", id); err != nil {
+			return err
+		}
+
+		type side struct {
+			team string
+			nums map[string]Key
+			used map[int64]int
+			keys map[Key]int64
+		}
+
+		sides := make(map[bool]*side)
+		for _, rw := range rows {
+			if !isPlayer(rw.PlayerStats) {
+				continue
+			}
+
+			s := sides[rw.Home]
+			if s == nil {
+				ks, err := keys(tx, team(rw.Team))
+				if err != nil {
+					return err
+				}
+				s = &side{team: team(rw.Team), nums: make(map[string]Key), used: make(map[int64]int), keys: ks}
+				sides[rw.Home] = s
+			}
+
+			k := rw.key()
+			if pid, ok := s.keys[k]; ok {
+				s.nums[k.Num] = k
+				s.used[pid]++
+			}
+		}
+
+		teams := map[int]bool{l.LiveMatch.HomeID: true, l.LiveMatch.AwayID: false}
+		seen := make(map[string]bool)
+
+		for _, a := range l.Live.Actions {
+			if a.ActorID == "" || a.PlayerNum == "" || seen[a.ActorID] {
+				continue
+			}
+
+			home, ok := teams[a.TeamID]
+			if !ok || sides[home] == nil {
+				continue
+			}
+			s := sides[home]
+
+			k, ok := s.nums[a.PlayerNum]
+			if !ok {
+				continue
+			}
+			seen[a.ActorID] = true
+
+			moved, err := link(tx, a.ActorID, s.team, k, s.keys[k], s.used)
+			if err != nil {
+				return fmt.Errorf("linking actor %s: %w", a.ActorID, err)
+			}
+			if moved != 0 {
+				s.used[s.keys[k]]--
+				s.used[moved]++
+				s.keys[k] = moved
+			}
+		}
+
+		return nil
+	})
+}
+
+// link links actor to the player of key k, or moves k to the player of actor,
+// returning the player k was moved to.
+func link(tx *sqlx.Tx, actor, team string, k Key, pid int64, used map[int64]int) (int64, error) {
+	var cur struct {
+		PlayerID int64 `db:"player_id"`
+		Manual   bool  `db:"manual"`
+	}
+
+	err := tx.Get(&cur, "SELECT player_id, manual FROM player_actors WHERE actor_id = ?", actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err := tx.Exec("INSERT INTO player_actors (actor_id, player_id) VALUES (?, ?)", actor, pid)
+		return 0, err
+	}
+	if err != nil || cur.PlayerID == pid || used[cur.PlayerID] > 0 {
+		return 0, err
+	}
+
+	var manual bool
+	if err := tx.Get(&manual, "SELECT manual FROM player_keys WHERE team = ? AND num = ? AND name = ?", team, k.Num, k.Name); err != nil {
+		return 0, err
+	}
+
+	if manual || cur.Manual {
+		return 0, nil
+	}
+
+	if d, err := distinct(tx, pid, cur.PlayerID); err != nil || d {
+		return 0, err
+	}
+
+	// Don't move keys across teams, e.g. a player who changed clubs.
+	var other string
+	if err := tx.Get(&other, "SELECT team FROM players WHERE id = ?", cur.PlayerID); err != nil || other != team {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("UPDATE player_keys SET player_id = ? WHERE team = ? AND num = ? AND name = ?", cur.PlayerID, team, k.Num, k.Name); err != nil {
+		return 0, err
+	}
+
+	return cur.PlayerID, prune(tx, pid)
+}
+
+// prune deletes the player if nothing links to it anymore.
+func prune(tx *sqlx.Tx, id int64) error {
+	_, err := tx.Exec(`DELETE FROM players WHERE id = ?1
+       AND NOT EXISTS (SELECT 1 FROM player_keys WHERE player_id = ?1)
+       AND NOT EXISTS (SELECT 1 FROM player_actors WHERE player_id = ?1)`, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM player_distinct WHERE (a = ?1 OR b = ?1) AND NOT EXISTS (SELECT 1 FROM players WHERE id = ?1)", id)
+	return err
+}
+
+func distinct(q sqlx.Queryer, a, b int64) (bool, error) {
+	if a > b {
+		a, b = b, a
+	}
+	var n int
+	err := sqlx.Get(q, &n, "SELECT COUNT(*) FROM player_distinct WHERE a = ? AND b = ?", a, b)
+	return n > 0, err
+}
+
+// IndexStore indexes every box score and play-by-play of the store, in the
+// order their matches were stored. Indexing is idempotent, so it only links
+// what's new.
+func (r *Registry) IndexStore() error {
+	var ids []string
+	err := r.db.Select(&ids, `SELECT d.match_id FROM match_details d LEFT JOIN match_results m ON m.matchId = d.match_id
+WHERE EXISTS (SELECT 1 FROM player_stats ps WHERE ps.match_id = d.match_id)
+ORDER BY m.id, d.match_id`)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		st, err := r.store.Stats(id)
+		if err != nil {
+			return err
+		}
+		if err := r.IndexStats(st); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		l, err := r.store.Live(id)
+		if err != nil {
+			return err
+		}
+		if len(l.Live.Actions) == 0 {
+			continue
+		}
+		if err := r.IndexLive(l); err != nil {
+			return fmt.Errorf("indexing play-by-play of %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) player(q sqlx.Queryer, id int64) (Player, error) {
+	var p Player
+
+	err := sqlx.Get(q, &p, "SELECT id, team, name FROM players WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, fmt.Errorf("player %d: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return p, err
+	}
+
+	if err := sqlx.Select(q, &p.Keys, "SELECT num, name FROM player_keys WHERE player_id = ? ORDER BY num, name", id); err != nil {
+		return p, err
+	}
+
+	err = sqlx.Select(q, &p.Actors, "SELECT actor_id FROM player_actors WHERE player_id = ? ORDER BY actor_id", id)
+
+	return p, err
+}
+
+// Player returns the player with the given ID.
+func (r *Registry) Player(id int64) (Player, error) { return r.player(r.db, id) }
+
+// Lookup returns the player of a box score row of the team.
+func (r *Registry) Lookup(team string, p cabb.PlayerStats) (Player, error) {
+	var id int64
+	err := r.db.Get(&id, "SELECT player_id FROM player_keys WHERE team = ? AND num = ? AND name = ?",
+		strings.TrimSpace(team), p.Num, Normalize(p.Name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Player{}, fmt.Errorf("player %s #%s of %s: %w", strings.TrimSpace(p.Name), p.Num, team, store.ErrNotFound)
+	}
+	if err != nil {
+		return Player{}, err
+	}
+	return r.Player(id)
+}
+
+// ByActor returns the player of an Action.ActorID.
+func (r *Registry) ByActor(actor string) (Player, error) {
+	var id int64
+	err := r.db.Get(&id, "SELECT player_id FROM player_actors WHERE actor_id = ?", actor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Player{}, fmt.Errorf("actor %s: %w", actor, store.ErrNotFound)
+	}
+	if err != nil {
+		return Player{}, err
+	}
+	return r.Player(id)
+}
+
+// Players returns the players of a team sorted by name.
+func (r *Registry) Players(team string) ([]Player, error) {
+	var ids []int64
+	if err := r.db.Select(&ids, "SELECT id FROM players WHERE team = ? ORDER BY name, id", strings.TrimSpace(team)); err != nil {
+		return nil, err
+	}
+
+	ps := make([]Player, len(ids))
+	for i, id := range ids {
+		p, err := r.Player(id)
+		if err != nil {
+			return nil, err
+		}
+		ps[i] = p
+	}
+
+	return ps, nil
+}
+
+// Teams returns the teams with players.
+func (r *Registry) Teams() ([]string, error) {
+	var ts []string
+	err := r.db.Select(&ts, "SELECT DISTINCT team FROM players ORDER BY team")
+	return ts, err
+}
+
+// Merge moves everything linked to the player from into the player into,
+// which must be of the same team, and deletes from. Its links become
+// overrides, so indexing never splits them again.
+func (r *Registry) Merge(into, from int64) error {
+	if into == from {
+		return nil
+	}
+
+	return r.tx(func(tx *sqlx.Tx) error {
+		a, err := r.player(tx, into)
+		if err != nil {
+			return err
+		}
+		b, err := r.player(tx, from)
+		if err != nil {
+			return err
+		}
+		if a.Team != b.Team {
+			return fmt.Errorf("merging players of different teams: %s and %s", a.Team, b.Team)
+		}
+
+		for _, q := range []string{
+			"UPDATE player_keys SET player_id = ?, manual = 1 WHERE player_id = ?",
+			"UPDATE player_actors SET player_id = ?, manual = 1 WHERE player_id = ?",
+		} {
+			if _, err := tx.Exec(q, into, from); err != nil {
+				return err
+			}
+		}
+
+		return prune(tx, from)
+	})
+}
+
+// Split moves the key of a player to a new player, returning it. Both players
+// are marked as distinct, and the key becomes an override.
+func (r *Registry) Split(id int64, k Key) (Player, error) {
+	k.Name = Normalize(k.Name)
+
+	var p Player
+
+	err := r.tx(func(tx *sqlx.Tx) error {
+		old, err := r.player(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var found bool
+		for _, ko := range old.Keys {
+			found = found || ko == k
+		}
+		if !found {
+			return fmt.Errorf("player %d has no %s #%s: %w", id, k.Name, k.Num, store.ErrNotFound)
+		}
+		if len(old.Keys) == 1 {
+			return fmt.Errorf("player %d has a single key", id)
+		}
+
+		name := k.Name
+		if Normalize(old.Name) == k.Name {
+			name = old.Name
+		}
+
+		nid, err := newPlayer(tx, old.Team, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("UPDATE player_keys SET player_id = ?, manual = 1 WHERE team = ? AND num = ? AND name = ?", nid, old.Team, k.Num, k.Name)
+		if err != nil {
+			return err
+		}
+
+		// Actors can't tell which of both they belong to anymore, so let the
+		// next indexing link them again.
+		if _, err := tx.Exec("DELETE FROM player_actors WHERE player_id = ? AND NOT manual", id); err != nil {
+			return err
+		}
+
+		if err := markDistinct(tx, id, nid); err != nil {
+			return err
+		}
+
+		p, err = r.player(tx, nid)
+		return err
+	})
+
+	return p, err
+}
+
+func markDistinct(tx *sqlx.Tx, a, b int64) error {
+	if a > b {
+		a, b = b, a
+	}
+	_, err := tx.Exec("INSERT INTO player_distinct (a, b) VALUES (?, ?) ON CONFLICT DO NOTHING", a, b)
+	return err
+}
+
+// Distinct marks two players as different people, so they aren't suggested
+// nor linked by their actors.
+func (r *Registry) Distinct(a, b int64) error {
+	if a == b {
+		return errors.New("a player can't be distinct from itself")
+	}
+
+	return r.tx(func(tx *sqlx.Tx) error {
+		for _, id := range []int64{a, b} {
+			if _, err := r.player(tx, id); err != nil {
+				return err
+			}
+		}
+		return markDistinct(tx, a, b)
+	})
+}
+
+// Totals are the aggregated stats of a player.
+type Totals struct {
+	Player Player
+	Games  int
+	Stats  cabb.PlayerStats
+}
+
+// appearances returns the box score rows of a team in the store with the
+// player they're linked to.
+func (r *Registry) appearances(team string) ([]row, []int64, error) {
+	team = strings.TrimSpace(team)
+
+	ks, err := keys(r.db, team)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []row
+	if err := r.db.Select(&rows, "SELECT * FROM player_stats WHERE TRIM(team) = ? ORDER BY rowid", team); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		res []row
+		ids []int64
+	)
+
+	for _, rw := range rows {
+		if !isPlayer(rw.PlayerStats) {
+			continue
+		}
+		id, ok := ks[rw.key()]
+		if !ok {
+			return nil, nil, fmt.Errorf("player %s #%s of %s in %s isn't indexed: %w",
+				strings.TrimSpace(rw.Name), rw.Num, team, rw.MatchID, store.ErrNotFound)
+		}
+		res = append(res, rw)
+		ids = append(ids, id)
+	}
+
+	return res, ids, nil
+}
+
+// Aggregate returns the stats of every player of a team over the matches in
+// the store, keyed by player instead of name. Only matches where the player
+// played count as games.
+func (r *Registry) Aggregate(team string) ([]Totals, error) {
+	rows, ids, err := r.appearances(team)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ts    []Totals
+		index = make(map[int64]int)
+		games = make(map[int64]map[string]bool)
+	)
+
+	for i, rw := range rows {
+		if rw.PlayedMillis == 0 {
+			continue
+		}
+
+		id := ids[i]
+		n, ok := index[id]
+		if !ok {
+			p, err := r.Player(id)
+			if err != nil {
+				return nil, err
+			}
+			n = len(ts)
+			index[id] = n
+			ts = append(ts, Totals{Player: p, Stats: cabb.PlayerStats{Name: p.Name}})
+			games[id] = make(map[string]bool)
+		}
+
+		ts[n].Stats = ts[n].Stats.Add(rw.PlayerStats)
+		if !games[id][rw.MatchID] {
+			games[id][rw.MatchID] = true
+			ts[n].Games++
+		}
+	}
+
+	sort.Slice(ts, func(i, j int) bool {
+		if ts[i].Player.Name != ts[j].Player.Name {
+			return ts[i].Player.Name < ts[j].Player.Name
+		}
+		return ts[i].Player.ID < ts[j].Player.ID
+	})
+
+	return ts, nil
+}
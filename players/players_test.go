@@ -0,0 +1,311 @@
+package players
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/store"
+)
+
+const home, away = "DEFENSORES", "ATLETICO"
+
+// registry returns a registry of a new store with a box score per match, each
+// a list of number and name of home players who played 10 minutes.
+func registry(t *testing.T, matches ...[]string) (*Registry, *store.Store) {
+	t.Helper()
+
+	s, err := store.Open(filepath.Join(t.TempDir(), "cabb.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	r := New(s)
+
+	for i, m := range matches {
+		st := stats(matchID(i), m...)
+		if err := s.SaveStats(st); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.IndexStats(st); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return r, s
+}
+
+func matchID(i int) string { return string(rune('A'+i)) + "01" }
+
+func stats(id string, players ...string) cabb.Stats {
+	var st cabb.Stats
+	st.MatchID = id
+	st.Match = cabb.LiveMatch{Home: home, HomeID: 1, Away: away, AwayID: 2}
+	for i := 0; i < len(players); i += 2 {
+		st.Stats.Home = append(st.Stats.Home, cabb.PlayerStats{Num: players[i], Name: players[i+1] + " ", Points: 2, PlayedMillis: 600000})
+	}
+	st.Stats.Home = append(st.Stats.Home, cabb.PlayerStats{Name: "TOTALES", Points: 2 * len(players) / 2})
+	return st
+}
+
+// roster returns the name and numbers of the players of the home team.
+func roster(t *testing.T, r *Registry) map[string][]string {
+	t.Helper()
+	ps, err := r.Players(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]string)
+	for _, p := range ps {
+		got[p.Name] = append(got[p.Name], p.Nums()...)
+	}
+	return got
+}
+
+func TestIndexStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches [][]string
+		want    map[string][]string
+		players int
+	}{
+		{
+			name:    "number change",
+			matches: [][]string{{"5", "PEREZ JUAN"}, {"9", "PEREZ JUAN"}},
+			want:    map[string][]string{"PEREZ JUAN": {"5", "9"}},
+			players: 1,
+		},
+		{
+			name:    "accents and punctuation",
+			matches: [][]string{{"5", "PÉREZ JUAN"}, {"5", "Perez, Juan."}},
+			want:    map[string][]string{"PÉREZ JUAN": {"5"}},
+			players: 1,
+		},
+		{
+			name:    "shared name",
+			matches: [][]string{{"5", "LOPEZ SANTIAGO", "15", "LOPEZ SANTIAGO"}, {"5", "LOPEZ SANTIAGO", "15", "LOPEZ SANTIAGO"}},
+			want:    map[string][]string{"LOPEZ SANTIAGO": {"5", "15"}},
+			players: 2,
+		},
+		{
+			// Someone else wears the number, so both get a new player.
+			name:    "shared name and number change",
+			matches: [][]string{{"5", "LOPEZ SANTIAGO", "15", "LOPEZ SANTIAGO"}, {"6", "LOPEZ SANTIAGO", "7", "LOPEZ SANTIAGO"}},
+			want:    map[string][]string{"LOPEZ SANTIAGO": {"5", "15", "6", "7"}},
+			players: 4,
+		},
+		{
+			name:    "spelling variant",
+			matches: [][]string{{"4", "FERNANDEZ JUAN"}, {"4", "FERNANDES JUAN"}},
+			want:    map[string][]string{"FERNANDEZ JUAN": {"4"}, "FERNANDES JUAN": {"4"}},
+			players: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := registry(t, tt.matches...)
+
+		ps, err := r.Players(home)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ps) != tt.players {
+			t.Errorf("%s: %d players, want %d", tt.name, len(ps), tt.players)
+		}
+
+		if got := roster(t, r); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: players = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r, _ := registry(t, []string{"5", "PEREZ JUAN", "7", " . "})
+
+	p, err := r.Lookup(" "+home, cabb.PlayerStats{Num: "5", Name: "Pérez Juan"})
+	if err != nil || p.Name != "PEREZ JUAN" {
+		t.Errorf("Lookup() = %+v, %v", p, err)
+	}
+
+	for _, ps := range []cabb.PlayerStats{
+		{Num: "7", Name: " . "},
+		{Name: "TOTALES"},
+		{Num: "6", Name: "PEREZ JUAN"},
+	} {
+		if _, err := r.Lookup(home, ps); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Lookup(%q #%s) error = %v, want %v", ps.Name, ps.Num, err, store.ErrNotFound)
+		}
+	}
+}
+
+func TestIndexLiveLinksVariants(t *testing.T) {
+	r, s := registry(t, []string{"4", "FERNANDEZ JUAN"}, []string{"4", "FERNANDES JUAN"})
+
+	for i := 0; i < 2; i++ {
+		var l cabb.Live
+		l.Match.MatchID = matchID(i)
+		l.LiveMatch = cabb.LiveMatch{Home: home, HomeID: 1, Away: away, AwayID: 2}
+		l.Live.Actions = []cabb.Action{{ActionNum: 1, Period: 1, TeamID: 1, ActorID: "actor-4", PlayerNum: "4", Type: "CANASTA-2P", MatchTime: "09:00"}}
+
+		if err := s.SaveLive(l); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.IndexLive(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ps, err := r.Players(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 || len(ps[0].Keys) != 2 || !reflect.DeepEqual(ps[0].Actors, []string{"actor-4"}) {
+		t.Errorf("players = %+v, want one with both spellings", ps)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches [][]string
+		want    [][2]string
+	}{
+		{
+			name:    "spelling variant",
+			matches: [][]string{{"4", "FERNANDEZ JUAN"}, {"8", "JUAN FERNANDES"}},
+			want:    [][2]string{{"FERNANDEZ JUAN", "JUAN FERNANDES"}},
+		},
+		{
+			name:    "played together",
+			matches: [][]string{{"4", "FERNANDEZ JUAN", "8", "FERNANDES JUAN"}},
+		},
+		{
+			name:    "different names",
+			matches: [][]string{{"4", "FERNANDEZ JUAN"}, {"8", "GOMEZ PEDRO"}},
+		},
+	}
+
+	for _, tt := range tests {
+		r, _ := registry(t, tt.matches...)
+
+		ss, err := r.Suggestions(home)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got [][2]string
+		for _, s := range ss {
+			if s.Score < MinSimilarity || s.Score > 1 {
+				t.Errorf("%s: score %v out of range", tt.name, s.Score)
+			}
+			got = append(got, [2]string{s.A.Name, s.B.Name})
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: suggestions = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	r, _ := registry(t, []string{"4", "FERNANDEZ JUAN"}, []string{"8", "FERNANDES JUAN"}, []string{"4", "FERNANDEZ JUAN"})
+
+	ss, err := r.Suggestions(home)
+	if err != nil || len(ss) != 1 {
+		t.Fatalf("Suggestions() = %v, %v", ss, err)
+	}
+	// Keep the spelling of the player of most matches.
+	a, b := ss[0].B, ss[0].A
+	if a.Name != "FERNANDEZ JUAN" {
+		t.Fatalf("suggestion = %+v", ss[0])
+	}
+
+	if err := r.Merge(a.ID, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Player(b.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("merged player still exists: %v", err)
+	}
+
+	// Indexing again keeps the merge.
+	if err := r.IndexStore(); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := r.Aggregate(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 1 || ts[0].Games != 3 || ts[0].Stats.Points != 6 {
+		t.Errorf("Aggregate() after merge = %+v", ts)
+	}
+
+	p, err := r.Split(a.ID, Key{Num: "8", Name: "Fernandes Juan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "FERNANDES JUAN" || !reflect.DeepEqual(p.Nums(), []string{"8"}) {
+		t.Errorf("Split() = %+v", p)
+	}
+
+	if err := r.IndexStore(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := roster(t, r), map[string][]string{"FERNANDEZ JUAN": {"4"}, "FERNANDES JUAN": {"8"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("players after split = %v, want %v", got, want)
+	}
+
+	// Split players are distinct, so they aren't suggested again.
+	if ss, err := r.Suggestions(home); err != nil || len(ss) != 0 {
+		t.Errorf("Suggestions() after split = %v, %v", ss, err)
+	}
+
+	if _, err := r.Split(a.ID, Key{Num: "4", Name: "FERNANDEZ JUAN"}); err == nil {
+		t.Error("split the only key of a player")
+	}
+	if _, err := r.Split(a.ID, Key{Num: "99", Name: "NOBODY"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Split() of an unknown key error = %v", err)
+	}
+	if err := r.Distinct(a.ID, a.ID); err == nil {
+		t.Error("player distinct from itself")
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	r, _ := registry(t, []string{"4", "FERNANDEZ JUAN"}, []string{"8", "FERNANDES JUAN"})
+
+	ss, err := r.Suggestions(home)
+	if err != nil || len(ss) != 1 {
+		t.Fatalf("Suggestions() = %v, %v", ss, err)
+	}
+
+	if err := r.Distinct(ss[0].B.ID, ss[0].A.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if ss, err := r.Suggestions(home); err != nil || len(ss) != 0 {
+		t.Errorf("Suggestions() after Distinct = %v, %v", ss, err)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"FERNANDEZ JUAN", "FERNANDEZ JUAN", 1, 1},
+		{"FERNANDEZ JUAN", "juan fernández", 1, 1},
+		{"FERNANDEZ JUAN", "JUAN FERNANDES", 0.9, 0.95},
+		{"FERNANDEZ JUAN", "GOMEZ PEDRO", 0, 0.5},
+		{"", "GOMEZ PEDRO", 0, 0},
+	}
+
+	for _, tt := range tests {
+		if s := Similarity(tt.a, tt.b); s < tt.min || s > tt.max {
+			t.Errorf("Similarity(%q, %q) = %v, want between %v and %v", tt.a, tt.b, s, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package cabb
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter safe for concurrent use. The same
+// Limiter can be shared by several clients so they're throttled together.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewLimiter returns a Limiter allowing rps requests per second on average,
+// with bursts of up to burst requests. A non positive rps disables limiting.
+func NewLimiter(rps float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Rate limit used by clients created without WithRateLimit or WithLimiter.
+const (
+	DefaultRateLimit = 5
+	DefaultBurst     = 5
+)
+
+// Wait blocks until a request is allowed or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+
+	d := l.reserve()
+	if d == 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// reserve takes a token, possibly in advance, and returns how long the caller
+// has to wait until it's actually available.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The time is read while holding the lock, but a caller could still see
+	// an earlier time than the previous one, which mustn't take tokens away.
+	now := l.now()
+	if !l.last.IsZero() {
+		if elapsed := now.Sub(l.last); elapsed > 0 {
+			l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+		}
+	}
+	if now.After(l.last) {
+		l.last = now
+	}
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel gives back a token taken by a reservation that wasn't used.
+func (l *Limiter) cancel() {
+	l.mu.Lock()
+	l.tokens = math.Min(l.burst, l.tokens+1)
+	l.mu.Unlock()
+}
+
+// WithRateLimit limits the requests made by the client to rps per second,
+// with bursts of up to burst requests.
+func WithRateLimit(rps float64, burst int) Option {
+	return WithLimiter(NewLimiter(rps, burst))
+}
+
+// WithLimiter makes the client use l, which can be shared with other clients.
+func WithLimiter(l *Limiter) Option {
+	return func(c *Client) { c.limiter = l }
+}
+
+// WithEndpointRateLimit overrides the client's rate limit for requests to the
+// given path, e.g. "envivo/partido.ashx".
+func WithEndpointRateLimit(path string, rps float64, burst int) Option {
+	return WithEndpointLimiter(path, NewLimiter(rps, burst))
+}
+
+// WithEndpointLimiter is like WithEndpointRateLimit but uses l, which can be
+// shared with other clients.
+func WithEndpointLimiter(path string, l *Limiter) Option {
+	return func(c *Client) {
+		if c.endpointLimiters == nil {
+			c.endpointLimiters = make(map[string]*Limiter)
+		}
+		c.endpointLimiters[path] = l
+	}
+}
+
+func (c *Client) wait(ctx context.Context, path string) error {
+	if l, ok := c.endpointLimiters[path]; ok {
+		return l.Wait(ctx)
+	}
+	return c.limiter.Wait(ctx)
+}
@@ -0,0 +1,111 @@
+package cabb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	start := time.Date(2023, 5, 1, 20, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	tests := []struct {
+		name  string
+		rps   float64
+		burst int
+		calls []int // milliseconds since start of each reservation
+		waits []time.Duration
+	}{
+		{
+			name: "burst then wait", rps: 10, burst: 2,
+			calls: []int{0, 0, 0, 0},
+			waits: []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name: "refill", rps: 10, burst: 2,
+			calls: []int{0, 0, 100, 300},
+			waits: []time.Duration{0, 0, 0, 0},
+		},
+		{
+			name: "refill capped at burst", rps: 10, burst: 2,
+			calls: []int{0, 10000, 10000, 10000},
+			waits: []time.Duration{0, 0, 0, 100 * time.Millisecond},
+		},
+		{
+			name: "minimum burst of one", rps: 2, burst: 0,
+			calls: []int{0, 0},
+			waits: []time.Duration{0, 500 * time.Millisecond},
+		},
+		{
+			// A caller that read the clock before the previous one.
+			name: "clock behind", rps: 10, burst: 2,
+			calls: []int{1000, 0, 1100},
+			waits: []time.Duration{0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		l := NewLimiter(tt.rps, tt.burst)
+		for i, ms := range tt.calls {
+			now := at(ms)
+			l.now = func() time.Time { return now }
+			if got := l.reserve(); got != tt.waits[i] {
+				t.Errorf("%s: call %d waits %v, want %v", tt.name, i, got, tt.waits[i])
+			}
+		}
+	}
+}
+
+func TestLimiterWaitCancel(t *testing.T) {
+	l := NewLimiter(1, 1)
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want deadline exceeded", err)
+	}
+
+	// The canceled wait gives its token back, so the next caller doesn't
+	// wait for it too.
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.1 {
+		t.Errorf("tokens = %.2f after canceling, want the reservation returned", tokens)
+	}
+}
+
+func TestLimiterCancelCapped(t *testing.T) {
+	l := NewLimiter(10, 2)
+
+	// Canceling more reservations than were taken, e.g. after a refill,
+	// doesn't go over the burst.
+	l.reserve()
+	for i := 0; i < 3; i++ {
+		l.cancel()
+	}
+
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens != 2 {
+		t.Errorf("tokens = %.2f, want the burst of 2", tokens)
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	for _, l := range []*Limiter{nil, NewLimiter(0, 1), NewLimiter(-1, 5)} {
+		for i := 0; i < 100; i++ {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
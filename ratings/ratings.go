@@ -0,0 +1,421 @@
+// Package ratings maintains Elo-style power ratings of teams from the results
+// of their matches.
+package ratings
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/standings"
+)
+
+// Defaults of new Ratings.
+const (
+	DefaultRating    = 1500.0
+	DefaultK         = 20.0
+	DefaultHomeCourt = 70.0
+
+	// PointsPerElo converts a rating difference into an expected margin of
+	// victory.
+	PointsPerElo = 28.0
+)
+
+// Entry is a rated match.
+type Entry struct {
+	MatchID    string    `json:"match_id"`
+	Kickoff    time.Time `json:"kickoff"`
+	Home       string    `json:"home"`
+	Away       string    `json:"away"`
+	HomeScore  int       `json:"home_score"`
+	AwayScore  int       `json:"away_score"`
+	HomeBefore float64   `json:"home_before"`
+	AwayBefore float64   `json:"away_before"`
+	HomeAfter  float64   `json:"home_after"`
+	AwayAfter  float64   `json:"away_after"`
+}
+
+// Ratings holds the rating of every team and the history of rated matches.
+// It's safe for concurrent use.
+//
+// Teams are keyed by their ID in the standings of a season when known, so a
+// team keeps its rating if its name is spelled differently, and by their
+// normalized name otherwise. Names holds the latest name each key was rated
+// with.
+type Ratings struct {
+	K         float64            `json:"k"`
+	HomeCourt float64            `json:"home_court"`
+	Teams     map[string]float64 `json:"teams"`
+	Names     map[string]string  `json:"names"`
+	IDs       map[string]int     `json:"ids"`
+	History   []Entry            `json:"history"`
+
+	mu    sync.RWMutex
+	rated map[string]bool
+}
+
+// New returns empty ratings with the default parameters.
+func New() *Ratings {
+	r := &Ratings{
+		K:         DefaultK,
+		HomeCourt: DefaultHomeCourt,
+	}
+	r.init()
+	return r
+}
+
+// init creates the maps missing, e.g. in ratings saved by older versions.
+func (r *Ratings) init() {
+	if r.Teams == nil {
+		r.Teams = make(map[string]float64)
+	}
+	if r.Names == nil {
+		r.Names = make(map[string]string)
+	}
+	if r.IDs == nil {
+		r.IDs = make(map[string]int)
+	}
+}
+
+func name(team string) string { return strings.TrimSpace(team) }
+
+func normalize(team string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(team), " "))
+}
+
+// team returns the key of the team with the given name.
+func (r *Ratings) team(name string) string {
+	n := normalize(name)
+	if id, ok := r.IDs[n]; ok {
+		return "#" + strconv.Itoa(id)
+	}
+	return n
+}
+
+// Rating returns the rating of the team, or DefaultRating if it hasn't played
+// yet.
+func (r *Ratings) Rating(team string) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rating(team)
+}
+
+func (r *Ratings) rating(team string) float64 {
+	if v, ok := r.Teams[r.team(team)]; ok {
+		return v
+	}
+	return DefaultRating
+}
+
+// key identifies a match, falling back to its teams and date for matches
+// without an ID.
+func key(id, home, away string, kickoff time.Time) string {
+	if id != "" {
+		return id
+	}
+	return name(home) + "|" + name(away) + "|" + kickoff.Format(time.RFC3339)
+}
+
+func (e Entry) key() string { return key(e.MatchID, e.Home, e.Away, e.Kickoff) }
+
+func matchKey(m cabb.Match) string { return key(m.MatchID, m.HomeTeam, m.AwayTeam, m.Kickoff) }
+
+// Rated reports whether the match was already rated.
+func (r *Ratings) Rated(m cabb.Match) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.isRated(m)
+}
+
+// isRated looks the match up in the index of rated matches, or in the history
+// if it wasn't built yet, e.g. for ratings just decoded.
+func (r *Ratings) isRated(m cabb.Match) bool {
+	k := matchKey(m)
+	if r.rated != nil {
+		return r.rated[k]
+	}
+	for _, e := range r.History {
+		if e.key() == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Ratings) index() {
+	r.rated = make(map[string]bool, len(r.History))
+	for _, e := range r.History {
+		r.rated[e.key()] = true
+	}
+}
+
+// Update rates a match. It returns false if the match doesn't count for the
+// standings or was already rated.
+func (r *Ratings) Update(m cabb.Match) bool {
+	return r.Add([]cabb.Match{m}) == 1
+}
+
+// update rates m after the last match of the history.
+func (r *Ratings) update(m cabb.Match) {
+	home, away := name(m.HomeTeam), name(m.AwayTeam)
+	hk, ak := r.team(home), r.team(away)
+	hr, ar := r.rating(home), r.rating(away)
+
+	hs, as := m.HomePoints.Points(), m.AwayPoints.Points()
+
+	var actual float64
+	if hs > as {
+		actual = 1
+	}
+
+	expected := r.expected(hr, ar)
+	delta := r.K * marginMultiplier(hs-as, hr+r.HomeCourt-ar) * (actual - expected)
+
+	r.Teams[hk], r.Teams[ak] = hr+delta, ar-delta
+	r.Names[hk], r.Names[ak] = home, away
+
+	r.History = append(r.History, Entry{
+		MatchID:    m.MatchID,
+		Kickoff:    m.Kickoff,
+		Home:       home,
+		Away:       away,
+		HomeScore:  hs,
+		AwayScore:  as,
+		HomeBefore: hr,
+		AwayBefore: ar,
+		HomeAfter:  hr + delta,
+		AwayAfter:  ar - delta,
+	})
+	r.rated[matchKey(m)] = true
+}
+
+// marginMultiplier scales the rating change by the margin of victory,
+// dampened when the favourite wins so ratings don't inflate.
+func marginMultiplier(margin int, diff float64) float64 {
+	if margin < 0 {
+		margin, diff = -margin, -diff
+	}
+	return math.Pow(float64(margin)+3, 0.8) / (7.5 + 0.006*diff)
+}
+
+func (r *Ratings) expected(home, away float64) float64 {
+	return 1 / (1 + math.Pow(10, (away-home-r.HomeCourt)/400))
+}
+
+// Add rates the matches in kickoff order, skipping those that don't count for
+// the standings or were already rated, and returns how many were rated. When a
+// match kicked off before the last rated one, every rating is recomputed from
+// all the known matches, so they don't depend on the order matches are added.
+func (r *Ratings) Add(ms []cabb.Match) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.add(ms, false)
+}
+
+// add rates ms, rating again every match in the history if rebuild is set.
+func (r *Ratings) add(ms []cabb.Match, rebuild bool) int {
+	if r.rated == nil {
+		r.index()
+	}
+
+	var (
+		add  []cabb.Match
+		seen = make(map[string]bool)
+	)
+	for _, m := range ms {
+		k := matchKey(m)
+		if standings.Counts(m) && !r.rated[k] && !seen[k] {
+			add = append(add, m)
+			seen[k] = true
+		}
+	}
+
+	if len(add) == 0 {
+		if rebuild {
+			r.rebuild()
+		}
+		return 0
+	}
+
+	sortMatches(add)
+
+	if n := len(r.History); n > 0 {
+		last, first := r.History[n-1], add[0]
+		if rebuild || less(first.Kickoff, matchKey(first), last.Kickoff, last.key()) {
+			r.rebuild(add...)
+			return len(add)
+		}
+	}
+
+	for _, m := range add {
+		r.update(m)
+	}
+
+	return len(add)
+}
+
+// rebuild rates again every match in the history along with ms, from the
+// default ratings.
+func (r *Ratings) rebuild(ms ...cabb.Match) {
+	for _, e := range r.History {
+		ms = append(ms, e.match())
+	}
+	sortMatches(ms)
+
+	r.Teams = make(map[string]float64)
+	r.Names = make(map[string]string)
+	r.History = nil
+	r.index()
+
+	for _, m := range ms {
+		r.update(m)
+	}
+}
+
+// sorted reports whether the history is in kickoff order.
+func (r *Ratings) sorted() bool {
+	return sort.SliceIsSorted(r.History, func(i, j int) bool {
+		a, b := r.History[i], r.History[j]
+		return less(a.Kickoff, a.key(), b.Kickoff, b.key())
+	})
+}
+
+// keyed reports whether every team in the history is rated by its key.
+func (r *Ratings) keyed() bool {
+	for _, e := range r.History {
+		if _, ok := r.Names[r.team(e.Home)]; !ok {
+			return false
+		}
+		if _, ok := r.Names[r.team(e.Away)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Entry) match() cabb.Match {
+	return cabb.Match{
+		MatchID:    e.MatchID,
+		HomeTeam:   e.Home,
+		AwayTeam:   e.Away,
+		HomePoints: cabb.NewScore(e.HomeScore),
+		AwayPoints: cabb.NewScore(e.AwayScore),
+		Kickoff:    e.Kickoff,
+		State:      cabb.StatusFinished,
+	}
+}
+
+// less orders matches by kickoff, and by key those at the same time.
+func less(at time.Time, k string, bt time.Time, bk string) bool {
+	if !at.Equal(bt) {
+		return at.Before(bt)
+	}
+	return k < bk
+}
+
+func sortMatches(ms []cabb.Match) {
+	sort.Slice(ms, func(i, j int) bool {
+		return less(ms[i].Kickoff, matchKey(ms[i]), ms[j].Kickoff, matchKey(ms[j]))
+	})
+}
+
+// AddSeason learns the IDs of the teams in the standings of the season and
+// rates every completed match of it. Teams rated before their ID was known
+// are keyed by it from then on, rating every match again.
+func (r *Ratings) AddSeason(s cabb.Season) int {
+	var ms []cabb.Match
+	for _, gd := range s.Season {
+		ms = append(ms, gd.Matches...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changed bool
+	for _, p := range s.Positions {
+		n := normalize(p.Name)
+		if p.ID == 0 || n == "" || cabb.IsBye(n) {
+			continue
+		}
+		if id, ok := r.IDs[n]; !ok || id != p.ID {
+			r.IDs[n] = p.ID
+			changed = true
+		}
+	}
+
+	return r.add(ms, changed && len(r.History) > 0)
+}
+
+// WinProbability returns the probability of the home team winning m.
+func (r *Ratings) WinProbability(m cabb.Match) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.expected(r.rating(m.HomeTeam), r.rating(m.AwayTeam))
+}
+
+// Spread returns the expected margin of victory of home over away, so Ratings
+// can be used as a simulation.Model.
+func (r *Ratings) Spread(home, away string) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return (r.rating(home) - r.rating(away) + r.HomeCourt) / PointsPerElo
+}
+
+// Power is the position of a team in the power table.
+type Power struct {
+	Rank   int
+	Team   string
+	Rating float64
+	Games  int
+	// Change is the rating change in the last match.
+	Change float64
+}
+
+// Table returns the teams sorted by rating.
+func (r *Ratings) Table() []Power {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ps := make(map[string]*Power, len(r.Teams))
+	for k, v := range r.Teams {
+		t, ok := r.Names[k]
+		if !ok {
+			t = k
+		}
+		ps[k] = &Power{Team: t, Rating: v}
+	}
+
+	for _, e := range r.History {
+		if p, ok := ps[r.team(e.Home)]; ok {
+			p.Games++
+			p.Change = e.HomeAfter - e.HomeBefore
+		}
+		if p, ok := ps[r.team(e.Away)]; ok {
+			p.Games++
+			p.Change = e.AwayAfter - e.AwayBefore
+		}
+	}
+
+	t := make([]Power, 0, len(ps))
+	for _, p := range ps {
+		t = append(t, *p)
+	}
+
+	sort.Slice(t, func(i, j int) bool {
+		if t[i].Rating != t[j].Rating {
+			return t[i].Rating > t[j].Rating
+		}
+		return t[i].Team < t[j].Team
+	})
+
+	for i := range t {
+		t[i].Rank = i + 1
+	}
+
+	return t
+}
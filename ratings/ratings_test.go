@@ -0,0 +1,227 @@
+package ratings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/inkel/cabb"
+)
+
+var day = time.Date(2024, time.April, 6, 0, 0, 0, 0, cabb.Argentina)
+
+func match(id string, days int, home string, hs int, away string, as int) cabb.Match {
+	return cabb.Match{
+		MatchID:    id,
+		HomeTeam:   home,
+		AwayTeam:   away,
+		HomePoints: cabb.NewScore(hs),
+		AwayPoints: cabb.NewScore(as),
+		Kickoff:    day.AddDate(0, 0, days),
+		State:      cabb.StatusFinished,
+	}
+}
+
+var season = []cabb.Match{
+	match("1", 0, "A", 70, "B", 60),
+	match("2", 0, "C", 50, "D", 65),
+	match("3", 7, "B", 80, "C", 60),
+	match("4", 7, "D", 55, "A", 56),
+	match("5", 14, "A", 61, "C", 59),
+	match("6", 14, "B", 70, "D", 71),
+}
+
+func TestAddIsOrderIndependent(t *testing.T) {
+	want := New()
+	if n := want.Add(season); n != len(season) {
+		t.Fatalf("Add() = %d, want %d", n, len(season))
+	}
+
+	reversed := make([]cabb.Match, len(season))
+	for i, m := range season {
+		reversed[len(season)-1-i] = m
+	}
+
+	tests := []struct {
+		name    string
+		batches [][]cabb.Match
+	}{
+		{"reversed", [][]cabb.Match{reversed}},
+		{"latest first", [][]cabb.Match{season[4:], season[2:4], season[:2]}},
+		{"one by one backwards", [][]cabb.Match{reversed[:1], reversed[1:2], reversed[2:3], reversed[3:4], reversed[4:5], reversed[5:]}},
+		{"repeated", [][]cabb.Match{season[3:], season, season[:3]}},
+	}
+
+	for _, tt := range tests {
+		r := New()
+		for _, b := range tt.batches {
+			r.Add(b)
+		}
+
+		if !reflect.DeepEqual(r.Teams, want.Teams) {
+			t.Errorf("%s: teams = %v, want %v", tt.name, r.Teams, want.Teams)
+		}
+		if !reflect.DeepEqual(r.History, want.History) {
+			t.Errorf("%s: history = %+v, want %+v", tt.name, r.History, want.History)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     cabb.Match
+		rated bool
+	}{
+		{"win", match("1", 0, "A", 70, "B", 60), true},
+		{"tie", match("2", 0, "A", 60, "B", 60), false},
+		{"not played", cabb.Match{MatchID: "3", HomeTeam: "A", AwayTeam: "B"}, false},
+		{"bye", match("4", 0, "A", 20, "LIBRE", 0), false},
+	}
+
+	for _, tt := range tests {
+		r := New()
+		if got := r.Update(tt.m); got != tt.rated {
+			t.Errorf("%s: Update() = %v, want %v", tt.name, got, tt.rated)
+		}
+		if got := r.Rated(tt.m); got != tt.rated {
+			t.Errorf("%s: Rated() = %v, want %v", tt.name, got, tt.rated)
+		}
+		if tt.rated && r.Update(tt.m) {
+			t.Errorf("%s: rated twice", tt.name)
+		}
+	}
+
+	r := New()
+	r.Update(match("1", 0, "A", 70, "B", 60))
+	if a, b := r.Rating("A"), r.Rating(" B "); a <= DefaultRating || b >= DefaultRating || a-DefaultRating != DefaultRating-b {
+		t.Errorf("ratings = %v, %v", a, b)
+	}
+}
+
+func TestLoadRebuildsUnsortedHistory(t *testing.T) {
+	want := New()
+	want.Add(season)
+
+	// Rate the latest matches first, as Add used to for batches browsed
+	// backwards.
+	old := New()
+	old.index()
+	for _, m := range []cabb.Match{season[5], season[4], season[3], season[2], season[1], season[0]} {
+		old.update(m)
+	}
+
+	b, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ratings.json")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.History) != len(want.History) {
+		t.Fatalf("history = %+v, want %+v", r.History, want.History)
+	}
+	for i, e := range r.History {
+		w := want.History[i]
+		if e.MatchID != w.MatchID || e.HomeAfter != w.HomeAfter || e.AwayAfter != w.AwayAfter {
+			t.Errorf("history %d = %+v, want %+v", i, e, w)
+		}
+	}
+	if !reflect.DeepEqual(r.Teams, want.Teams) {
+		t.Errorf("teams = %v, want %v", r.Teams, want.Teams)
+	}
+	for _, m := range season {
+		if !r.Rated(m) {
+			t.Errorf("match %s not rated", m.MatchID)
+		}
+	}
+}
+
+func TestTeamNames(t *testing.T) {
+	r := New()
+	r.Add([]cabb.Match{match("1", 0, "Club A", 70, "B", 60), match("2", 7, " CLUB  A", 70, "b", 60)})
+
+	if n := len(r.Teams); n != 2 {
+		t.Errorf("teams = %v, want 2", r.Teams)
+	}
+	if a := r.Table()[0]; a.Team != "CLUB  A" || a.Games != 2 {
+		t.Errorf("first = %+v, want CLUB  A after 2 games", a)
+	}
+}
+
+func TestAddSeasonTeamIDs(t *testing.T) {
+	season := func(pos []cabb.Position, ms ...cabb.Match) cabb.Season {
+		return cabb.Season{Season: []cabb.GameDay{{Matches: ms}}, Positions: pos}
+	}
+
+	r := New()
+	r.Add([]cabb.Match{match("1", 0, "Club A", 70, "B", 60)})
+	before := r.Rating("Club A")
+
+	// Learning the IDs keys the teams already rated by them.
+	r.AddSeason(season([]cabb.Position{{Name: "CLUB A", ID: 1}, {Name: "B", ID: 2}, {Name: "LIBRE"}}))
+	if _, ok := r.Teams["#1"]; !ok || len(r.Teams) != 2 || r.Rating("Club A") != before {
+		t.Fatalf("teams = %v, want keyed by ID", r.Teams)
+	}
+
+	// The same team in another tournament, with another name.
+	r.AddSeason(season([]cabb.Position{{Name: "Club A U17", ID: 1}, {Name: "C", ID: 3}},
+		match("2", 7, "Club A U17", 80, "C", 60)))
+
+	if len(r.Teams) != 3 || r.Rating("Club A") != r.Rating("Club A U17") {
+		t.Errorf("teams = %v, want Club A U17 rated as Club A", r.Teams)
+	}
+	if a := r.Table()[0]; a.Team != "Club A U17" || a.Games != 2 {
+		t.Errorf("first = %+v, want Club A U17 after 2 games", a)
+	}
+
+	path := filepath.Join(t.TempDir(), "ratings.json")
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l.Teams, r.Teams) || !reflect.DeepEqual(l.IDs, r.IDs) || !reflect.DeepEqual(l.Names, r.Names) {
+		t.Errorf("loaded %+v, want %+v", l, r)
+	}
+}
+
+func TestLoadKeysOldRatings(t *testing.T) {
+	want := New()
+	want.Add(season)
+
+	// Saved before teams had keys, by name.
+	b, err := json.Marshal(map[string]any{
+		"k":          want.K,
+		"home_court": want.HomeCourt,
+		"teams":      map[string]float64{"A": 1},
+		"history":    want.History,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ratings.json")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Teams, want.Teams) || !reflect.DeepEqual(r.Names, want.Names) {
+		t.Errorf("teams = %v %v, want %v %v", r.Teams, r.Names, want.Teams, want.Names)
+	}
+}
@@ -0,0 +1,72 @@
+package ratings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPath returns the file where ratings are persisted by default, in the
+// user cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cabb", "ratings.json"), nil
+}
+
+// Load reads the ratings saved at path. If the file doesn't exist it returns
+// empty ratings.
+func Load(path string) (*Ratings, error) {
+	r := New()
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading ratings: %w", err)
+	}
+
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("decoding ratings %s: %w", path, err)
+	}
+	r.init()
+	if !r.sorted() || !r.keyed() {
+		// Saved before ratings were kept in kickoff order, or keyed by
+		// team ID.
+		r.rebuild()
+	}
+
+	return r, nil
+}
+
+// Save writes the ratings to path, creating its directory if needed. The file
+// is replaced atomically.
+func (r *Ratings) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("saving ratings: %w", err)
+	}
+
+	r.mu.RLock()
+	b, err := json.MarshalIndent(r, "", "  ")
+	r.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encoding ratings: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("saving ratings: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("saving ratings: %w", err)
+	}
+
+	return nil
+}
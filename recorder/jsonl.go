@@ -0,0 +1,47 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// JSONL is a Sink writing one JSON encoded entry per line.
+type JSONL struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	c   io.Closer
+}
+
+// NewJSONL returns a JSONL sink writing to w.
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{enc: json.NewEncoder(w)}
+}
+
+// OpenJSONL returns a JSONL sink appending to the file at path.
+func OpenJSONL(path string) (*JSONL, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := NewJSONL(f)
+	j.c = f
+
+	return j, nil
+}
+
+func (j *JSONL) Record(e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.enc.Encode(e)
+}
+
+// Close closes the underlying file if the sink was created with OpenJSONL.
+func (j *JSONL) Close() error {
+	if j.c == nil {
+		return nil
+	}
+	return j.c.Close()
+}
@@ -0,0 +1,46 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	j := NewJSONL(&buf)
+
+	in := []Entry{
+		{URL: "http://a/dispositivo.ashx", Form: "accion=acceso", Status: 200, Latency: time.Millisecond},
+		{URL: "http://a/misequiposV2.ashx", Error: "connection refused"},
+	}
+	for _, e := range in {
+		if err := j.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out []Entry
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		out = append(out, e)
+	}
+
+	if len(out) != len(in) {
+		t.Fatalf("got %d lines, want %d", len(out), len(in))
+	}
+	for i := range in {
+		if out[i].URL != in[i].URL || out[i].Form != in[i].Form || out[i].Latency != in[i].Latency || out[i].Error != in[i].Error {
+			t.Errorf("line %d = %+v, want %+v", i, out[i], in[i])
+		}
+	}
+}
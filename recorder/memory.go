@@ -0,0 +1,23 @@
+package recorder
+
+import "sync"
+
+// Memory is a Sink keeping entries in memory, useful for tests.
+type Memory struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (m *Memory) Record(e Entry) error {
+	m.mu.Lock()
+	m.entries = append(m.entries, e)
+	m.mu.Unlock()
+	return nil
+}
+
+// Entries returns a copy of the recorded entries.
+func (m *Memory) Entries() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Entry(nil), m.entries...)
+}
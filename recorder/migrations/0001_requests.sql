@@ -0,0 +1,2 @@
+-- Table written by the original package-level D flag.
+CREATE TABLE IF NOT EXISTS requests (url TEXT, qs TEXT, body JSON);
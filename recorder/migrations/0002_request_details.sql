@@ -0,0 +1,8 @@
+-- Method, status, headers, latency and errors of each request.
+ALTER TABLE requests ADD COLUMN method TEXT;
+ALTER TABLE requests ADD COLUMN status INTEGER;
+ALTER TABLE requests ADD COLUMN request_headers JSON;
+ALTER TABLE requests ADD COLUMN response_headers JSON;
+ALTER TABLE requests ADD COLUMN latency_ms INTEGER;
+ALTER TABLE requests ADD COLUMN error TEXT;
+ALTER TABLE requests ADD COLUMN recorded_at TEXT;
@@ -0,0 +1,173 @@
+// Package recorder provides an http.RoundTripper that records the requests
+// made to the CABB API and their responses, to help debugging and to build
+// cassettes for offline development.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Entry is a recorded request and its response.
+type Entry struct {
+	Time           time.Time     `json:"time"`
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	Form           string        `json:"form"`
+	RequestHeader  http.Header   `json:"request_header,omitempty"`
+	Status         int           `json:"status"`
+	ResponseHeader http.Header   `json:"response_header,omitempty"`
+	Latency        time.Duration `json:"latency"`
+	Body           string        `json:"body"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// Sink stores recorded entries. Implementations must be safe for concurrent
+// use.
+type Sink interface {
+	Record(Entry) error
+}
+
+// RedactedParams are the form parameters whose values are never recorded.
+var RedactedParams = []string{"key", "uid"}
+
+// RedactedFields are the fields of JSON responses whose values are never
+// recorded, like the session key returned by dispositivo.ashx.
+var RedactedFields = []string{"key"}
+
+const redacted = "REDACTED"
+
+// Transport records every request going through it into Sink.
+type Transport struct {
+	Sink Sink
+
+	// Next performs the actual requests. Defaults to http.DefaultTransport.
+	Next http.RoundTripper
+
+	// OnError is called when an entry cannot be recorded. If nil, the error
+	// is returned by RoundTrip instead.
+	OnError func(error)
+}
+
+// New returns a Transport recording into sink the requests performed by next.
+func New(sink Sink, next http.RoundTripper) *Transport {
+	return &Transport{Sink: sink, Next: next}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	var form []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+		form = b
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	e := Entry{
+		Time:          time.Now(),
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		Form:          redact(string(form)),
+		RequestHeader: req.Header.Clone(),
+	}
+
+	res, err := next.RoundTrip(req)
+	e.Latency = time.Since(e.Time)
+
+	if err != nil {
+		e.Error = err.Error()
+		if rerr := t.record(e); rerr != nil {
+			return nil, fmt.Errorf("%w (recording: %v)", err, rerr)
+		}
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	e.Status = res.StatusCode
+	e.ResponseHeader = res.Header.Clone()
+	e.Body = redactBody(body)
+
+	if err := t.record(e); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (t *Transport) record(e Entry) error {
+	err := t.Sink.Record(e)
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("recording request to %s: %w", e.URL, err)
+	if t.OnError != nil {
+		t.OnError(err)
+		return nil
+	}
+
+	return err
+}
+
+func redact(form string) string {
+	vs, err := url.ParseQuery(form)
+	if err != nil {
+		return form
+	}
+
+	for _, k := range RedactedParams {
+		if vs.Has(k) {
+			vs.Set(k, redacted)
+		}
+	}
+
+	return vs.Encode()
+}
+
+// redactBody returns the response body with RedactedFields replaced. Bodies
+// without any of them are kept as they are.
+func redactBody(body []byte) string {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return string(body)
+	}
+
+	var found bool
+	for _, k := range RedactedFields {
+		if _, ok := obj[k]; ok {
+			obj[k] = json.RawMessage(`"` + redacted + `"`)
+			found = true
+		}
+	}
+	if !found {
+		return string(body)
+	}
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return string(body)
+	}
+
+	return string(b)
+}
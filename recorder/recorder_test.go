@@ -0,0 +1,124 @@
+package recorder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTransportRecords(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"resultado":"correcto","equipo":"`+r.PostForm.Get("id_equipo")+`"}`)
+	}))
+	defer srv.Close()
+
+	var mem Memory
+	hc := &http.Client{Transport: New(&mem, nil)}
+
+	form := url.Values{"accion": {"detalleEquipo"}, "id_equipo": {"DEF17"}, "key": {"secret"}, "uid": {"me"}}
+	res, err := hc.PostForm(srv.URL+"/misequiposV2.ashx", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if want := `{"resultado":"correcto","equipo":"DEF17"}`; string(body) != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+
+	es := mem.Entries()
+	if len(es) != 1 {
+		t.Fatalf("%d entries recorded, want 1", len(es))
+	}
+
+	e := es[0]
+
+	tests := []struct {
+		field, got, want string
+	}{
+		{"method", e.Method, http.MethodPost},
+		{"url", e.URL, srv.URL + "/misequiposV2.ashx"},
+		{"form", e.Form, "accion=detalleEquipo&id_equipo=DEF17&key=REDACTED&uid=REDACTED"},
+		{"body", e.Body, string(body)},
+		{"content type", e.ResponseHeader.Get("Content-Type"), "application/json"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+
+	if e.Status != http.StatusOK || e.Error != "" {
+		t.Errorf("status = %d, error = %q", e.Status, e.Error)
+	}
+}
+
+func TestTransportRedactsResponseKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"resultado":"correcto","key":"s3cr3t"}`)
+	}))
+	defer srv.Close()
+
+	var mem Memory
+	hc := &http.Client{Transport: New(&mem, nil)}
+
+	res, err := hc.PostForm(srv.URL+"/dispositivo.ashx", url.Values{"accion": {"acceso"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	// The client gets the key, but it's never recorded.
+	if !strings.Contains(string(body), "s3cr3t") {
+		t.Errorf("body = %s, want the key", body)
+	}
+
+	e := mem.Entries()[0]
+	if strings.Contains(e.Body, "s3cr3t") {
+		t.Errorf("recorded body %s has the key", e.Body)
+	}
+	if want := `{"key":"REDACTED","resultado":"correcto"}`; e.Body != want {
+		t.Errorf("recorded body = %s, want %s", e.Body, want)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{"resultado":"correcto","key":"abc"}`, `{"key":"REDACTED","resultado":"correcto"}`},
+		{`{"resultado":"correcto", "misequipos": []}`, `{"resultado":"correcto", "misequipos": []}`},
+		{`not json with "key":"abc"`, `not json with "key":"abc"`},
+		{`[{"key":"abc"}]`, `[{"key":"abc"}]`},
+		{``, ``},
+	}
+
+	for _, tt := range tests {
+		if got := redactBody([]byte(tt.in)); got != tt.want {
+			t.Errorf("redactBody(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTransportRecordsErrors(t *testing.T) {
+	var mem Memory
+	hc := &http.Client{Transport: New(&mem, nil)}
+
+	_, err := hc.PostForm("http://127.0.0.1:1/dispositivo.ashx", url.Values{"accion": {"acceso"}})
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+
+	es := mem.Entries()
+	if len(es) != 1 || !strings.Contains(es[0].Error, "connect") {
+		t.Fatalf("entries = %+v, want the failed request", es)
+	}
+}
@@ -0,0 +1,182 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// IgnoredParams are the form parameters left out when matching requests
+// against a cassette, as they change from session to session.
+var IgnoredParams = []string{"key", "uid", "id_dispositivo"}
+
+// UnmatchedRequestError is returned by a Cassette when there's no recorded
+// response for a request.
+type UnmatchedRequestError struct {
+	Method string
+	URL    string
+	Key    string
+}
+
+func (e *UnmatchedRequestError) Error() string {
+	return fmt.Sprintf("cassette: no recorded response for %s %s (%s)", e.Method, e.URL, e.Key)
+}
+
+// Cassette is an http.RoundTripper serving recorded responses instead of
+// performing requests. Requests are matched by endpoint and form parameters;
+// when the same request was recorded several times, the responses are served
+// in order and the last one is repeated after that.
+type Cassette struct {
+	mu     sync.Mutex
+	tracks map[string]*track
+}
+
+type track struct {
+	entries []Entry
+	next    int
+}
+
+// NewCassette returns a Cassette serving entries.
+func NewCassette(entries []Entry) *Cassette {
+	c := &Cassette{tracks: make(map[string]*track)}
+
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			continue
+		}
+
+		k := key(u, e.Form)
+
+		t, ok := c.tracks[k]
+		if !ok {
+			t = &track{}
+			c.tracks[k] = t
+		}
+		t.entries = append(t.entries, e)
+	}
+
+	return c
+}
+
+// OpenCassette loads a cassette from a file written by a JSONL sink.
+func OpenCassette(path string) (*Cassette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	es, err := ReadJSONL(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette %s: %w", path, err)
+	}
+
+	return NewCassette(es), nil
+}
+
+// ReadJSONL reads the entries written by a JSONL sink.
+func ReadJSONL(r io.Reader) ([]Entry, error) {
+	var es []Entry
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64<<20)
+
+	for n := 1; s.Scan(); n++ {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		es = append(es, e)
+	}
+
+	return es, s.Err()
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var form []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+		form = b
+	}
+
+	k := key(req.URL, string(form))
+
+	e, ok := c.play(k)
+	if !ok {
+		return nil, &UnmatchedRequestError{Method: req.Method, URL: req.URL.String(), Key: k}
+	}
+
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	hdr := e.ResponseHeader.Clone()
+	if hdr == nil {
+		hdr = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        hdr,
+		Body:          io.NopCloser(strings.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (c *Cassette) play(k string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tracks[k]
+	if !ok {
+		return Entry{}, false
+	}
+
+	e := t.entries[t.next]
+	if t.next < len(t.entries)-1 {
+		t.next++
+	}
+
+	return e, true
+}
+
+// key identifies a request by its endpoint and normalized form parameters.
+func key(u *url.URL, form string) string {
+	vs, err := url.ParseQuery(form)
+	if err != nil {
+		return u.Path + "?" + form
+	}
+
+	for _, p := range IgnoredParams {
+		vs.Del(p)
+	}
+
+	return strings.TrimPrefix(u.Path, "/") + "?" + vs.Encode()
+}
@@ -0,0 +1,173 @@
+package recorder_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/recorder"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func replayClient(t *testing.T, c *recorder.Cassette) *cabb.Client {
+	t.Helper()
+
+	// No retries, so unmatched requests fail right away.
+	client, err := cabb.NewClient("uid", "d",
+		cabb.WithHTTPClient(&http.Client{Transport: c}),
+		cabb.WithBaseURL("http://cassette.invalid"),
+		cabb.WithRetryPolicy(cabb.RetryPolicy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func golden(t *testing.T, name string, v any) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s; run go test -update if the change is expected", name, path)
+	}
+}
+
+func TestReplayGolden(t *testing.T) {
+	c, err := recorder.OpenCassette(filepath.Join("testdata", "season.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := replayClient(t, c)
+
+	s, err := client.Season("DEF17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "season", s)
+
+	m := s.Season[0].Matches[0]
+
+	st, err := client.Stats(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "stats", st)
+
+	l, err := client.Live(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "live", l)
+
+	// Parsed values, which aren't part of the JSON encoding.
+	if !m.Played() || m.HomePoints.Points() != 64 || m.AwayPoints.Points() != 60 || m.State != cabb.StatusFinished {
+		t.Errorf("match = %s %v (%s), want a finished 64 - 60", m.Title(), m.Played(), m.State)
+	}
+}
+
+func TestReplayIsDeterministic(t *testing.T) {
+	var runs [2][]byte
+
+	for i := range runs {
+		c, err := recorder.OpenCassette(filepath.Join("testdata", "season.jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := replayClient(t, c).Season("DEF17")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if runs[i], err = json.Marshal(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(runs[0], runs[1]) {
+		t.Error("replaying the same cassette twice gave different seasons")
+	}
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	c, err := recorder.OpenCassette(filepath.Join("testdata", "season.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = replayClient(t, c).Season("NOPE")
+
+	var unmatched *recorder.UnmatchedRequestError
+	if !errors.As(err, &unmatched) {
+		t.Fatalf("err = %v, want an UnmatchedRequestError", err)
+	}
+}
+
+func TestCassetteTracks(t *testing.T) {
+	es := []recorder.Entry{
+		{URL: "http://a/envivo/partido.ashx", Form: "id_partido=M01&key=old", Status: 200, Body: "first"},
+		{URL: "http://a/envivo/partido.ashx", Form: "id_partido=M01&key=old", Status: 200, Body: "second"},
+		{URL: "http://a/envivo/partido.ashx", Form: "id_partido=M02", Error: "connection reset"},
+	}
+
+	c := recorder.NewCassette(es)
+	hc := &http.Client{Transport: c}
+
+	tests := []struct {
+		form url.Values
+		body string
+		err  bool
+	}{
+		// key is ignored, and the order of the parameters doesn't matter.
+		{url.Values{"key": {"new"}, "id_partido": {"M01"}}, "first", false},
+		{url.Values{"id_partido": {"M01"}}, "second", false},
+		// The last response repeats.
+		{url.Values{"id_partido": {"M01"}}, "second", false},
+		{url.Values{"id_partido": {"M02"}}, "", true},
+		{url.Values{"id_partido": {"M03"}}, "", true},
+	}
+
+	for i, tt := range tests {
+		res, err := hc.PostForm("http://b/envivo/partido.ashx", tt.form)
+		if (err != nil) != tt.err {
+			t.Fatalf("request %d: err = %v, want error %v", i, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+
+		b, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(b) != tt.body {
+			t.Errorf("request %d: body = %q, want %q", i, b, tt.body)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package recorder
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/inkel/cabb/migrate"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns the schema migrations of the requests database.
+func Migrations() ([]migrate.Migration, error) {
+	return migrate.Load(migrations, "migrations")
+}
+
+// Migrate brings the requests database up to date. Databases written before
+// migrations were tracked already have every column of the requests table if
+// they have recorded_at, so they are only marked as migrated.
+func Migrate(db *sql.DB) ([]migrate.Migration, error) {
+	ms, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('requests') WHERE name = 'recorded_at'").Scan(&n)
+	if err != nil {
+		return nil, err
+	}
+
+	if n > 0 {
+		if err := migrate.Baseline(db, ms, 2); err != nil {
+			return nil, err
+		}
+	}
+
+	return migrate.Up(db, ms)
+}
+
+// SQLite is a Sink storing entries in the requests table of a sqlite
+// database.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the sqlite database at path, applying any
+// pending migration.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests are recorded concurrently, and sqlite allows a single writer.
+	db.SetMaxOpenConns(1)
+
+	if _, err := Migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Record(e Entry) error {
+	reqHdr, err := json.Marshal(e.RequestHeader)
+	if err != nil {
+		return err
+	}
+
+	resHdr, err := json.Marshal(e.ResponseHeader)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`INSERT INTO requests (url, qs, body, method, status, request_headers, response_headers, latency_ms, error, recorded_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.URL, e.Form, e.Body, e.Method, e.Status, reqHdr, resHdr, e.Latency.Milliseconds(), e.Error, e.Time.Format(time.RFC3339Nano))
+
+	return err
+}
+
+func (s *SQLite) Close() error { return s.db.Close() }
+
+// Entries returns every request recorded in the database, in the order they
+// were recorded.
+func (s *SQLite) Entries() ([]Entry, error) { return entries(s.db) }
+
+// OpenSQLiteCassette loads a cassette from a database written by a SQLite
+// sink. The database is opened read-only, so a wrong path is reported instead
+// of creating it and replaying an empty cassette.
+func OpenSQLiteCassette(path string) (*Cassette, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("opening cassette: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	es, err := entries(db)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette %s: %w", path, err)
+	}
+
+	return NewCassette(es), nil
+}
+
+const (
+	entriesQuery = `SELECT url, COALESCE(qs, ''), COALESCE(body, ''), COALESCE(method, ''), COALESCE(status, 0),
+COALESCE(response_headers, 'null'), COALESCE(latency_ms, 0), COALESCE(error, ''), COALESCE(recorded_at, '')
+FROM requests ORDER BY rowid`
+
+	// legacyEntriesQuery reads the table written by the package-level D flag,
+	// which only had the URL, form and body of each request.
+	legacyEntriesQuery = `SELECT url, COALESCE(qs, ''), COALESCE(body, ''), '', 0, 'null', 0, '', ''
+FROM requests ORDER BY rowid`
+)
+
+func entries(db *sql.DB) ([]Entry, error) {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('requests') WHERE name = 'recorded_at'").Scan(&n)
+	if err != nil {
+		return nil, err
+	}
+
+	q := entriesQuery
+	if n == 0 {
+		q = legacyEntriesQuery
+	}
+
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var es []Entry
+
+	for rows.Next() {
+		var (
+			e       Entry
+			hdr, at string
+			ms      int64
+		)
+
+		if err := rows.Scan(&e.URL, &e.Form, &e.Body, &e.Method, &e.Status, &hdr, &ms, &e.Error, &at); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(hdr), &e.ResponseHeader); err != nil {
+			return nil, fmt.Errorf("decoding headers of %s: %w", e.URL, err)
+		}
+
+		e.Latency = time.Duration(ms) * time.Millisecond
+		e.Time, _ = time.Parse(time.RFC3339Nano, at)
+
+		es = append(es, e)
+	}
+
+	return es, rows.Err()
+}
@@ -0,0 +1,153 @@
+package recorder
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.db")
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	at := time.Date(2023, 5, 6, 18, 0, 0, 0, time.UTC)
+	err = db.Record(Entry{
+		Time:           at,
+		Method:         http.MethodPost,
+		URL:            "http://a/envivo/partido.ashx",
+		Form:           "id_partido=M01&key=REDACTED",
+		Status:         200,
+		ResponseHeader: http.Header{"Content-Type": {"application/json"}},
+		Latency:        20 * time.Millisecond,
+		Body:           `{"resultado":"correcto"}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		url, form, body, method, headers, recordedAt string
+		status, latency                              int
+	)
+	err = db.db.QueryRow(`SELECT url, qs, body, method, status, response_headers, latency_ms, recorded_at FROM requests`).
+		Scan(&url, &form, &body, &method, &status, &headers, &latency, &recordedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field     string
+		got, want any
+	}{
+		{"url", url, "http://a/envivo/partido.ashx"},
+		{"form", form, "id_partido=M01&key=REDACTED"},
+		{"body", body, `{"resultado":"correcto"}`},
+		{"method", method, http.MethodPost},
+		{"status", status, 200},
+		{"headers", headers, `{"Content-Type":["application/json"]}`},
+		{"latency", latency, 20},
+		{"recorded at", recordedAt, "2023-05-06T18:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.db")
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2023, 5, 6, 18, 0, 0, 0, time.UTC)
+	for i, body := range []string{"first", "second"} {
+		err := db.Record(Entry{
+			Time:           at.Add(time.Duration(i) * time.Second),
+			Method:         http.MethodPost,
+			URL:            "http://a/envivo/partido.ashx",
+			Form:           "id_partido=M01",
+			Status:         200,
+			ResponseHeader: http.Header{"Content-Type": {"application/json"}},
+			Latency:        20 * time.Millisecond,
+			Body:           body,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := OpenSQLiteCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := c.tracks["envivo/partido.ashx?id_partido=M01"]
+	if tr == nil || len(tr.entries) != 2 || tr.entries[1].Body != "second" || !tr.entries[0].Time.Equal(at) {
+		t.Fatalf("track = %+v", tr)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("opening the cassette modified the database")
+	}
+}
+
+func TestSQLiteCassetteMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typo.db")
+
+	if _, err := OpenSQLiteCassette(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err = %v, want not exist", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("opening a missing cassette created it")
+	}
+}
+
+func TestSQLiteCassetteLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE requests (url TEXT, qs TEXT, body JSON);
+INSERT INTO requests VALUES ('http://a/misequiposV2.ashx', 'accion=listado', '{"resultado":"correcto"}');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := OpenSQLiteCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tr := c.tracks["misequiposV2.ashx?accion=listado"]; tr == nil || tr.entries[0].Body != `{"resultado":"correcto"}` {
+		t.Fatalf("tracks = %+v", c.tracks)
+	}
+}
@@ -0,0 +1,174 @@
+package cabb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with transient errors are
+// retried. Requests that change state on the server are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values lower than 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay and MaxDelay bound the exponential backoff between attempts.
+	// MaxDelay also caps the wait asked by a Retry-After header.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Backoff overrides the default exponential backoff with jitter. attempt
+	// is the number of the attempt that just failed, starting at 1.
+	Backoff func(attempt int) time.Duration
+
+	// Retryable reports whether err is worth retrying. Defaults to
+	// IsTransient.
+	Retryable func(err error) bool
+
+	// OnRetry, if set, is called before waiting for the next attempt.
+	OnRetry func(RetryAttempt)
+}
+
+// RetryAttempt describes a failed attempt that is going to be retried.
+type RetryAttempt struct {
+	Endpoint string
+	Attempt  int
+	Err      error
+	Delay    time.Duration
+}
+
+// DefaultRetryPolicy is used by clients created without WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// WithRetryPolicy sets the policy used to retry transient failures.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// IsTransient reports whether err is likely to go away on a new attempt:
+// network errors, 429 and 5xx responses.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var se *HTTPStatusError
+	if errors.As(err, &se) {
+		switch se.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+func (p RetryPolicy) run(ctx context.Context, endpoint string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := p.delay(attempt, err)
+
+		if p.OnRetry != nil {
+			p.OnRetry(RetryAttempt{
+				Endpoint: endpoint,
+				Attempt:  attempt,
+				Err:      err,
+				Delay:    delay,
+			})
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-t.C:
+		}
+	}
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransient(err)
+}
+
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	// Honor Retry-After, but don't let the server stall the client longer
+	// than MaxDelay.
+	var se *HTTPStatusError
+	if errors.As(err, &se) && se.RetryAfter > 0 {
+		if p.MaxDelay > 0 && se.RetryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return se.RetryAfter
+	}
+
+	if p.Backoff != nil {
+		return p.Backoff(attempt)
+	}
+
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	// Jitter between half and the whole delay, so concurrent clients don't
+	// retry in lockstep.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// parseRetryAfter parses the Retry-After header, either in seconds or as an
+// HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,127 @@
+package cabb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// unmatched is a RoundTripper failure that no retry fixes.
+type unmatched struct{}
+
+func (unmatched) Error() string { return "no recorded response" }
+
+func TestIsTransient(t *testing.T) {
+	urlErr := func(err error) error { return &url.Error{Op: "Post", URL: "http://x/", Err: err} }
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", &HTTPStatusError{StatusCode: 429}, true},
+		{"500", &HTTPStatusError{StatusCode: 500}, true},
+		{"503 wrapped", fmt.Errorf("season: %w", &HTTPStatusError{StatusCode: 503}), true},
+		{"404", &HTTPStatusError{StatusCode: 404}, false},
+		{"501", &HTTPStatusError{StatusCode: 501}, false},
+		{"timeout", urlErr(&net.DNSError{Err: "timeout", IsTimeout: true}), true},
+		{"connection refused", urlErr(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), true},
+		{"connection reset", urlErr(syscall.ECONNRESET), true},
+		{"EOF", urlErr(io.EOF), true},
+		{"unexpected EOF", urlErr(io.ErrUnexpectedEOF), true},
+		{"unsupported scheme", urlErr(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"unmatched cassette request", urlErr(unmatched{}), false},
+		{"canceled", urlErr(context.Canceled), false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"API error", &APIError{Message: "no hay estadísticas"}, false},
+		{"decode error", &DecodeError{Err: errors.New("invalid character")}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("%s: IsTransient(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name     string
+		attempt  int
+		err      error
+		min, max time.Duration
+	}{
+		{"first backoff", 1, errors.New("x"), 50 * time.Millisecond, 100 * time.Millisecond},
+		{"third backoff", 3, errors.New("x"), 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped backoff", 10, errors.New("x"), 500 * time.Millisecond, time.Second},
+		{"retry after", 1, &HTTPStatusError{StatusCode: 429, RetryAfter: 300 * time.Millisecond}, 300 * time.Millisecond, 300 * time.Millisecond},
+		{"retry after capped", 1, &HTTPStatusError{StatusCode: 429, RetryAfter: time.Hour}, time.Second, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := p.delay(tt.attempt, tt.err); d < tt.min || d > tt.max {
+				t.Fatalf("%s: delay = %v, want between %v and %v", tt.name, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRetryRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		errs  []error
+		calls int
+	}{
+		{"success", []error{nil}, 1},
+		{"transient then success", []error{&HTTPStatusError{StatusCode: 503}, nil}, 2},
+		{"permanent", []error{&APIError{Message: "x"}, nil}, 1},
+		{"exhausted", []error{io.EOF, io.EOF, io.EOF, nil}, 3},
+	}
+
+	for _, tt := range tests {
+		p := RetryPolicy{MaxAttempts: 3, Backoff: func(int) time.Duration { return 0 }}
+
+		var calls int
+		err := p.run(context.Background(), "test", func() error {
+			err := tt.errs[calls]
+			calls++
+			return err
+		})
+
+		if calls != tt.calls {
+			t.Errorf("%s: %d calls, want %d", tt.name, calls, tt.calls)
+		}
+		if want := tt.errs[calls-1]; err != want {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package simulation
+
+import "github.com/inkel/cabb/standings"
+
+// DefaultHomeCourt is the points advantage of playing at home.
+const DefaultHomeCourt = 3.0
+
+// PointDiff rates teams by their average point difference per game.
+type PointDiff struct {
+	Ratings   map[string]float64
+	HomeCourt float64
+}
+
+// NewPointDiff rates the teams of the standings. The point difference is
+// regressed towards zero by adding two even games to every team, so a blowout
+// in the first game day doesn't dominate the simulation.
+func NewPointDiff(t standings.Table) PointDiff {
+	p := PointDiff{
+		Ratings:   make(map[string]float64, len(t)),
+		HomeCourt: DefaultHomeCourt,
+	}
+
+	for _, s := range t {
+		p.Ratings[s.Team] = float64(s.Diff()) / float64(s.Played+2)
+	}
+
+	return p
+}
+
+func (p PointDiff) Spread(home, away string) float64 {
+	return p.Ratings[home] - p.Ratings[away] + p.HomeCourt
+}
@@ -0,0 +1,286 @@
+// Package simulation estimates the final standings of a season by playing its
+// remaining matches many times.
+package simulation
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/standings"
+)
+
+// Model predicts the outcome of a match.
+type Model interface {
+	// Spread returns the expected margin of victory of home over away.
+	Spread(home, away string) float64
+}
+
+// Defaults used when simulating.
+const (
+	DefaultRuns  = 10000
+	DefaultSigma = 11.0
+)
+
+// Odds are the simulated outcomes of a team.
+type Odds struct {
+	Team string
+	// Positions holds the probability of finishing in each position, with
+	// the first place at index 0.
+	Positions []float64
+	Qualify   float64
+	Relegate  float64
+	// Points and Pos are the expected league points and position.
+	Points float64
+	Pos    float64
+}
+
+type config struct {
+	runs     int
+	workers  int
+	sigma    float64
+	qualify  int
+	relegate int
+	seed     int64
+	model    Model
+}
+
+type Option func(*config)
+
+// Runs sets the number of simulated seasons.
+func Runs(n int) Option { return func(c *config) { c.runs = n } }
+
+// Workers sets how many seasons are simulated concurrently.
+func Workers(n int) Option { return func(c *config) { c.workers = n } }
+
+// Sigma sets the standard deviation of the margin of victory.
+func Sigma(s float64) Option { return func(c *config) { c.sigma = s } }
+
+// Qualify sets how many teams at the top of the table qualify.
+func Qualify(n int) Option { return func(c *config) { c.qualify = n } }
+
+// Relegate sets how many teams at the bottom of the table are relegated.
+func Relegate(n int) Option { return func(c *config) { c.relegate = n } }
+
+// Seed makes the simulation reproducible.
+func Seed(n int64) Option { return func(c *config) { c.seed = n } }
+
+// WithModel sets the model used to predict matches, by default NewPointDiff
+// of the current standings.
+func WithModel(m Model) Option { return func(c *config) { c.model = m } }
+
+// Remaining returns the matches of the season that don't count yet for the
+// standings. Byes aren't matches to play.
+func Remaining(s cabb.Season) []cabb.Match {
+	var ms []cabb.Match
+	for _, gd := range s.Season {
+		for _, m := range gd.Matches {
+			if m.IsBye() || strings.TrimSpace(m.HomeTeam) == "" || strings.TrimSpace(m.AwayTeam) == "" {
+				continue
+			}
+			if !standings.Counts(m) {
+				ms = append(ms, m)
+			}
+		}
+	}
+	return ms
+}
+
+// Simulate plays the remaining matches of the season and returns the odds of
+// every team, sorted by expected position.
+func Simulate(ctx context.Context, s cabb.Season, opts ...Option) ([]Odds, error) {
+	results := standings.Results(s.Season)
+	table := standings.Compute(s)
+
+	cfg := config{
+		runs:    DefaultRuns,
+		workers: runtime.GOMAXPROCS(0),
+		sigma:   DefaultSigma,
+		seed:    time.Now().UnixNano(),
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.model == nil {
+		cfg.model = NewPointDiff(table)
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+
+	teams := make([]string, len(table))
+	index := make(map[string]int, len(table))
+	for i, t := range table {
+		teams[i] = t.Team
+		index[t.Team] = i
+	}
+
+	var games []game
+	for _, m := range Remaining(s) {
+		h, a := strings.TrimSpace(m.HomeTeam), strings.TrimSpace(m.AwayTeam)
+		games = append(games, game{home: h, away: a, spread: cfg.model.Spread(h, a)})
+	}
+
+	base := average(table)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total = newTally(len(teams))
+	)
+
+	for w := 0; w < cfg.workers; w++ {
+		runs := cfg.runs / cfg.workers
+		if w < cfg.runs%cfg.workers {
+			runs++
+		}
+
+		wg.Add(1)
+		go func(w, runs int) {
+			defer wg.Done()
+
+			rng := rand.New(rand.NewSource(cfg.seed + int64(w)))
+			t := newTally(len(teams))
+			rs := make([]standings.Result, len(results), len(results)+len(games))
+			copy(rs, results)
+
+			for i := 0; i < runs; i++ {
+				if i%100 == 0 && ctx.Err() != nil {
+					return
+				}
+
+				rs = rs[:len(results)]
+				for _, g := range games {
+					rs = append(rs, g.play(rng, base, cfg.sigma))
+				}
+
+				for _, st := range standings.FromResults(rs, teams) {
+					t.add(index[st.Team], st.Pos-1, st.Points)
+				}
+				t.runs++
+			}
+
+			mu.Lock()
+			total.merge(t)
+			mu.Unlock()
+		}(w, runs)
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return total.odds(teams, cfg.qualify, cfg.relegate), nil
+}
+
+type game struct {
+	home, away string
+	spread     float64
+}
+
+// play draws the result of the game from a normal distribution of the margin
+// of victory around its spread, with base points for an even team.
+func (g game) play(rng *rand.Rand, base, sigma float64) standings.Result {
+	margin := math.Round(g.spread + rng.NormFloat64()*sigma)
+	if margin == 0 {
+		margin = 1
+		if rng.Intn(2) == 0 {
+			margin = -1
+		}
+	}
+
+	home := math.Round(base + margin/2)
+
+	return standings.Result{
+		Home:      g.home,
+		Away:      g.away,
+		HomeScore: int(home),
+		AwayScore: int(home - margin),
+	}
+}
+
+// average returns the points per game scored by a team in the league.
+func average(t standings.Table) float64 {
+	var pts, games int
+	for _, s := range t {
+		pts += s.Scored
+		games += s.Played
+	}
+	if games == 0 {
+		return 60
+	}
+	return float64(pts) / float64(games)
+}
+
+type tally struct {
+	runs      int
+	positions [][]int
+	points    []int
+}
+
+func newTally(n int) *tally {
+	t := &tally{positions: make([][]int, n), points: make([]int, n)}
+	for i := range t.positions {
+		t.positions[i] = make([]int, n)
+	}
+	return t
+}
+
+func (t *tally) add(team, pos, points int) {
+	t.positions[team][pos]++
+	t.points[team] += points
+}
+
+func (t *tally) merge(o *tally) {
+	t.runs += o.runs
+	for i := range t.positions {
+		for j := range t.positions[i] {
+			t.positions[i][j] += o.positions[i][j]
+		}
+		t.points[i] += o.points[i]
+	}
+}
+
+func (t *tally) odds(teams []string, qualify, relegate int) []Odds {
+	res := make([]Odds, len(teams))
+
+	if t.runs == 0 {
+		return res
+	}
+
+	n := float64(t.runs)
+
+	for i, team := range teams {
+		o := Odds{
+			Team:      team,
+			Positions: make([]float64, len(teams)),
+			Points:    float64(t.points[i]) / n,
+		}
+
+		for p, c := range t.positions[i] {
+			f := float64(c) / n
+			o.Positions[p] = f
+			o.Pos += float64(p+1) * f
+			if p < qualify {
+				o.Qualify += f
+			}
+			if p >= len(teams)-relegate {
+				o.Relegate += f
+			}
+		}
+
+		res[i] = o
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Pos < res[j].Pos })
+
+	return res
+}
@@ -0,0 +1,124 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/cabb"
+)
+
+func match(home, hs, away, as, status string) cabb.Match {
+	m := cabb.Match{MatchID: home + "-" + away, HomeTeam: home, AwayTeam: away, HomeScore: hs, AwayScore: as, Status: status}
+	m.Parse()
+	return m
+}
+
+// season is a league of three teams where each one has a bye per round.
+func season() cabb.Season {
+	return cabb.Season{Season: []cabb.GameDay{
+		{Matches: []cabb.Match{
+			match("A", "70", "B", "60", "Finalizado"),
+			match("C", "", "LIBRE", "", ""),
+		}},
+		{Matches: []cabb.Match{
+			match("B", "", "C", "", "Programado"),
+			match("LIBRE", "", "A", "", ""),
+		}},
+		{Matches: []cabb.Match{
+			match("C", "", "A", "", "Programado"),
+			match("Libre ", "", "B", "", "Programado"),
+			match("", "", "", "", ""),
+		}},
+	}}
+}
+
+func TestRemaining(t *testing.T) {
+	var got []string
+	for _, m := range Remaining(season()) {
+		got = append(got, m.MatchID)
+	}
+
+	if want := []string{"B-C", "C-A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Remaining() = %v, want %v", got, want)
+	}
+}
+
+type even struct{}
+
+func (even) Spread(home, away string) float64 { return 0 }
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		qualify float64
+	}{
+		// No team qualifies unless asked.
+		{"point difference", nil, 0},
+		{"model", []Option{WithModel(even{})}, 0},
+		{"qualify", []Option{Qualify(2), Relegate(1)}, 2},
+	}
+
+	for _, tt := range tests {
+		opts := append([]Option{Runs(2000), Workers(3), Seed(1)}, tt.opts...)
+
+		os, err := Simulate(context.Background(), season(), opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if len(os) != 3 {
+			t.Fatalf("%s: got %d teams, want 3: %+v", tt.name, len(os), os)
+		}
+
+		var qualify float64
+		for _, o := range os {
+			if cabb.IsBye(o.Team) {
+				t.Errorf("%s: simulated a bye: %+v", tt.name, o)
+			}
+			if len(o.Positions) != 3 {
+				t.Errorf("%s: %s has %d positions, want 3", tt.name, o.Team, len(o.Positions))
+			}
+
+			var sum float64
+			for _, p := range o.Positions {
+				sum += p
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("%s: %s positions add up to %v", tt.name, o.Team, sum)
+			}
+
+			qualify += o.Qualify
+		}
+
+		if math.Abs(qualify-tt.qualify) > 1e-9 {
+			t.Errorf("%s: qualify odds add up to %v, want %v", tt.name, qualify, tt.qualify)
+		}
+	}
+}
+
+func TestSimulateIsReproducible(t *testing.T) {
+	a, err := Simulate(context.Background(), season(), Runs(500), Seed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Simulate(context.Background(), season(), Runs(500), Seed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed, different odds:\n%+v\n%+v", a, b)
+	}
+}
+
+func TestSimulateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Simulate(ctx, season(), Runs(1000)); err != context.Canceled {
+		t.Errorf("Simulate() error = %v, want %v", err, context.Canceled)
+	}
+}
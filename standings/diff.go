@@ -0,0 +1,76 @@
+package standings
+
+import (
+	"fmt"
+
+	"github.com/inkel/cabb"
+)
+
+// Inconsistency is a difference between the computed standings and the
+// clasificacion reported by the API.
+type Inconsistency struct {
+	Team     string
+	Field    string
+	API      int
+	Computed int
+	// Missing is set when the team is only present in one of the tables, in
+	// which case API and Computed are not set.
+	Missing bool
+}
+
+func (i Inconsistency) String() string {
+	if i.Missing {
+		return fmt.Sprintf("%s: %s", i.Team, i.Field)
+	}
+	return fmt.Sprintf("%s: %s API %d, computed %d", i.Team, i.Field, i.API, i.Computed)
+}
+
+// Diff compares the computed standings against the positions reported by the
+// API.
+func Diff(t Table, pos []cabb.Position) []Inconsistency {
+	var is []Inconsistency
+
+	seen := make(map[string]bool, len(pos))
+
+	for _, p := range pos {
+		if cabb.IsBye(p.Name) {
+			continue
+		}
+
+		s, ok := t.Team(p.Name)
+		if !ok {
+			is = append(is, Inconsistency{Team: p.Name, Field: "missing from computed standings", Missing: true})
+			continue
+		}
+		seen[normalize(s.Team)] = true
+
+		fields := []struct {
+			name          string
+			api, computed int
+		}{
+			{"position", p.Pos, s.Pos},
+			{"played", p.Played, s.Played},
+			{"won", p.Won, s.Won},
+			{"lost", p.Lost, s.Lost},
+			{"points for", p.Scored, s.Scored},
+			{"points against", p.Received, s.Received},
+			{"league points", p.Score, s.Points},
+		}
+
+		for _, f := range fields {
+			if f.api != f.computed {
+				is = append(is, Inconsistency{Team: p.Name, Field: f.name, API: f.api, Computed: f.computed})
+			}
+		}
+	}
+
+	if len(pos) > 0 {
+		for _, s := range t {
+			if !seen[normalize(s.Team)] {
+				is = append(is, Inconsistency{Team: s.Team, Field: "missing from API clasificacion", Missing: true})
+			}
+		}
+	}
+
+	return is
+}
@@ -0,0 +1,280 @@
+// Package standings computes league tables from the results of a season,
+// independently of the clasificacion reported by the CABB API.
+package standings
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/inkel/cabb"
+)
+
+// League points awarded for each result.
+const (
+	WinPoints     = 2
+	LossPoints    = 1
+	ForfeitPoints = 0
+)
+
+// Standing is the record of a team in the league.
+type Standing struct {
+	Pos      int
+	Team     string
+	Played   int
+	Won      int
+	Lost     int
+	Forfeits int
+	Scored   int
+	Received int
+	Points   int
+}
+
+// Diff returns the point difference of the team.
+func (s Standing) Diff() int { return s.Scored - s.Received }
+
+// Table is a list of standings sorted by position.
+type Table []Standing
+
+// Team returns the standing of the team with the given name.
+func (t Table) Team(name string) (Standing, bool) {
+	name = normalize(name)
+	for _, s := range t {
+		if normalize(s.Team) == name {
+			return s, true
+		}
+	}
+	return Standing{}, false
+}
+
+// Result is a match that counts for the standings.
+type Result struct {
+	Home, Away           string
+	HomeScore, AwayScore int
+	Forfeit              bool
+}
+
+// Counts reports whether m has a result that counts for the standings:
+// finished or forfeited matches with both scores, and matches with both
+// scores and an unknown status. Live and suspended matches, ties and byes
+// don't count.
+func Counts(m cabb.Match) bool {
+	if !m.Played() || m.IsBye() {
+		return false
+	}
+
+	switch m.State {
+	case cabb.StatusFinished, cabb.StatusForfeit, cabb.StatusUnknown:
+		return m.HomePoints != m.AwayPoints
+	}
+
+	return false
+}
+
+// Results returns the matches of the game days that count for the standings.
+func Results(days []cabb.GameDay) []Result {
+	var rs []Result
+
+	for _, gd := range days {
+		for _, m := range gd.Matches {
+			if !Counts(m) {
+				continue
+			}
+
+			rs = append(rs, Result{
+				Home:      strings.TrimSpace(m.HomeTeam),
+				Away:      strings.TrimSpace(m.AwayTeam),
+				HomeScore: m.HomePoints.Points(),
+				AwayScore: m.AwayPoints.Points(),
+				Forfeit:   m.State == cabb.StatusForfeit,
+			})
+		}
+	}
+
+	return rs
+}
+
+// Compute returns the standings of the season.
+func Compute(s cabb.Season) Table {
+	return FromResults(Results(s.Season), teams(s))
+}
+
+// teams returns the name of every team of the season, so teams without
+// results are also included. Byes aren't teams.
+func teams(s cabb.Season) []string {
+	var ts []string
+	add := func(name string) {
+		if !cabb.IsBye(name) {
+			ts = append(ts, strings.TrimSpace(name))
+		}
+	}
+	for _, p := range s.Positions {
+		add(p.Name)
+	}
+	for _, gd := range s.Season {
+		for _, m := range gd.Matches {
+			add(m.HomeTeam)
+			add(m.AwayTeam)
+		}
+	}
+	return ts
+}
+
+// FromResults computes the standings from a list of results, sorted by league
+// points and the FIBA tiebreak rules. Every team in teams is included even if
+// it has no results. Team names are compared like Table.Team does, and each
+// standing takes the first spelling of its name.
+func FromResults(rs []Result, teams []string) Table {
+	byTeam := make(map[string]*Standing)
+
+	get := func(name string) *Standing {
+		k := normalize(name)
+		s, ok := byTeam[k]
+		if !ok {
+			s = &Standing{Team: strings.TrimSpace(name)}
+			byTeam[k] = s
+		}
+		return s
+	}
+
+	for _, n := range teams {
+		if normalize(n) != "" {
+			get(n)
+		}
+	}
+
+	for _, r := range rs {
+		h, a := get(r.Home), get(r.Away)
+		h.record(r.HomeScore, r.AwayScore, r.Forfeit)
+		a.record(r.AwayScore, r.HomeScore, r.Forfeit)
+	}
+
+	ss := make([]Standing, 0, len(byTeam))
+	for _, s := range byTeam {
+		ss = append(ss, *s)
+	}
+
+	t := Table(rank(ss, rs))
+	for i := range t {
+		t[i].Pos = i + 1
+	}
+
+	return t
+}
+
+func (s *Standing) record(scored, received int, forfeit bool) {
+	s.Played++
+	s.Scored += scored
+	s.Received += received
+
+	switch {
+	case scored > received:
+		s.Won++
+		s.Points += WinPoints
+	case forfeit:
+		s.Lost++
+		s.Forfeits++
+		s.Points += ForfeitPoints
+	default:
+		s.Lost++
+		s.Points += LossPoints
+	}
+}
+
+// rank sorts the standings by league points, and teams tied on points using
+// the FIBA rules:
+//
+//  1. league points in the games between the tied teams;
+//  2. point difference in the games between the tied teams;
+//  3. points scored in the games between the tied teams;
+//  4. point difference in all games;
+//  5. points scored in all games.
+//
+// Whenever a criterion splits the tied teams, the procedure starts over for
+// the teams that remain tied. Teams tied after every criterion are sorted by
+// name.
+func rank(ss []Standing, rs []Result) []Standing {
+	sort.Slice(ss, func(i, j int) bool { return ss[i].Points > ss[j].Points })
+
+	var out []Standing
+	for _, g := range split(ss, func(s Standing) int { return s.Points }) {
+		out = append(out, tiebreak(g, rs)...)
+	}
+	return out
+}
+
+func tiebreak(ss []Standing, rs []Result) []Standing {
+	if len(ss) < 2 {
+		return ss
+	}
+
+	h2h := headToHead(ss, rs)
+
+	criteria := []func(Standing) int{
+		func(s Standing) int { return h2h[normalize(s.Team)].Points },
+		func(s Standing) int { return h2h[normalize(s.Team)].Diff() },
+		func(s Standing) int { return h2h[normalize(s.Team)].Scored },
+		Standing.Diff,
+		func(s Standing) int { return s.Scored },
+	}
+
+	for _, c := range criteria {
+		sort.SliceStable(ss, func(i, j int) bool { return c(ss[i]) > c(ss[j]) })
+
+		gs := split(ss, c)
+		if len(gs) == 1 {
+			continue
+		}
+
+		var out []Standing
+		for _, g := range gs {
+			out = append(out, tiebreak(g, rs)...)
+		}
+		return out
+	}
+
+	sort.SliceStable(ss, func(i, j int) bool { return ss[i].Team < ss[j].Team })
+
+	return ss
+}
+
+// headToHead returns the records of the teams in the games played between
+// them, by normalized name.
+func headToHead(ss []Standing, rs []Result) map[string]*Standing {
+	h2h := make(map[string]*Standing, len(ss))
+	for _, s := range ss {
+		h2h[normalize(s.Team)] = &Standing{Team: s.Team}
+	}
+
+	for _, r := range rs {
+		h, ok1 := h2h[normalize(r.Home)]
+		a, ok2 := h2h[normalize(r.Away)]
+		if !ok1 || !ok2 {
+			continue
+		}
+		h.record(r.HomeScore, r.AwayScore, r.Forfeit)
+		a.record(r.AwayScore, r.HomeScore, r.Forfeit)
+	}
+
+	return h2h
+}
+
+// split groups consecutive standings with the same value of f. ss must be
+// sorted by f.
+func split(ss []Standing, f func(Standing) int) [][]Standing {
+	var gs [][]Standing
+
+	for i := 0; i < len(ss); {
+		j := i + 1
+		for j < len(ss) && f(ss[j]) == f(ss[i]) {
+			j++
+		}
+		gs = append(gs, ss[i:j])
+		i = j
+	}
+
+	return gs
+}
+
+func normalize(name string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(name), " "))
+}
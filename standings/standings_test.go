@@ -0,0 +1,178 @@
+package standings
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/inkel/cabb"
+)
+
+func TestFromResults(t *testing.T) {
+	r := func(home string, hs int, away string, as int) Result {
+		return Result{Home: home, Away: away, HomeScore: hs, AwayScore: as}
+	}
+
+	tests := []struct {
+		name  string
+		rs    []Result
+		teams []string
+		want  []string
+	}{
+		{
+			name:  "league points",
+			rs:    []Result{r("A", 60, "B", 70), r("B", 50, "C", 40), r("C", 80, "A", 70)},
+			teams: []string{"A", "B", "C", "D"},
+			want:  []string{"B", "C", "A", "D"},
+		},
+		{
+			// A and B have 5 points, A won their game although B has a
+			// better point difference.
+			name: "head to head points",
+			rs: []Result{
+				r("A", 61, "B", 60), r("A", 50, "D", 70), r("A", 70, "C", 60),
+				r("B", 100, "C", 40), r("B", 90, "D", 40), r("C", 70, "D", 60),
+			},
+			want: []string{"A", "B", "C", "D"},
+		},
+		{
+			// A and B split their games, A by a wider margin.
+			name: "head to head point difference",
+			rs:   []Result{r("B", 61, "A", 60), r("A", 70, "B", 60), r("A", 51, "C", 50), r("B", 100, "C", 40)},
+			want: []string{"A", "B", "C"},
+		},
+		{
+			// Every team won one game, only the difference between them
+			// matters.
+			name: "three way tie",
+			rs:   []Result{r("B", 61, "A", 60), r("A", 100, "C", 50), r("C", 70, "B", 60)},
+			want: []string{"A", "B", "C"},
+		},
+		{
+			// Every team won one game by 10 points.
+			name: "head to head points scored",
+			rs:   []Result{r("A", 70, "B", 60), r("B", 60, "C", 50), r("C", 80, "A", 70)},
+			want: []string{"A", "C", "B"},
+		},
+		{
+			// A and B didn't play each other.
+			name: "overall point difference",
+			rs:   []Result{r("A", 80, "C", 70), r("B", 80, "D", 60)},
+			want: []string{"B", "A", "C", "D"},
+		},
+		{
+			name:  "name",
+			teams: []string{"C", "A", "B"},
+			want:  []string{"A", "B", "C"},
+		},
+		{
+			name: "forfeit",
+			rs: []Result{
+				r("A", 20, "B", 0), r("C", 60, "D", 50),
+				{Home: "B", Away: "D", HomeScore: 0, AwayScore: 20, Forfeit: true},
+			},
+			want: []string{"D", "A", "C", "B"},
+		},
+		{
+			// The same teams spelled differently by the feed, named after
+			// their first spelling.
+			name: "spelling",
+			rs: []Result{
+				r("Club A", 70, "CLUB B", 60), r("club  b", 80, "C", 60),
+				r("C", 50, " Club A ", 70),
+			},
+			teams: []string{"CLUB A", "C"},
+			want:  []string{"CLUB A", "CLUB B", "C"},
+		},
+		{
+			// Like head to head points, spelling the game between A and B
+			// differently.
+			name: "head to head spelling",
+			rs: []Result{
+				r("A", 50, "D", 70), r("A", 70, "C", 60), r("B", 100, "C", 40),
+				r("B", 90, "D", 40), r("C", 70, "D", 60), r(" a", 61, "b ", 60),
+			},
+			want: []string{"A", "B", "C", "D"},
+		},
+	}
+
+	for _, tt := range tests {
+		tb := FromResults(tt.rs, tt.teams)
+
+		var got []string
+		for i, s := range tb {
+			if s.Pos != i+1 {
+				t.Errorf("%s: %s position = %d, want %d", tt.name, s.Team, s.Pos, i+1)
+			}
+			got = append(got, s.Team)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: order = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestForfeitPoints(t *testing.T) {
+	tb := FromResults([]Result{{Home: "A", Away: "B", HomeScore: 20, AwayScore: 0, Forfeit: true}}, nil)
+
+	b, _ := tb.Team("b")
+	if b.Points != ForfeitPoints || b.Forfeits != 1 || b.Lost != 1 {
+		t.Errorf("B = %+v, want a forfeit", b)
+	}
+}
+
+func TestComputeByes(t *testing.T) {
+	match := func(home, hs, away, as, status string) cabb.Match {
+		m := cabb.Match{HomeTeam: home, AwayTeam: away, HomeScore: hs, AwayScore: as, Status: status}
+		m.Parse()
+		return m
+	}
+
+	s := cabb.Season{
+		Season: []cabb.GameDay{
+			{Matches: []cabb.Match{
+				match("A ", "70", "B", "60", "Finalizado"),
+				match("C", "", "LIBRE", "", ""),
+			}},
+			{Matches: []cabb.Match{
+				match("LIBRE", "20", "A", "0", "Finalizado"),
+				match("B", "50", " Libre ", "0", "Finalizado"),
+				match("C", "", "B", "", "Programado"),
+			}},
+		},
+		Positions: []cabb.Position{
+			{Name: "A", Pos: 1, Played: 1, Won: 1, Score: 2, Scored: 70, Received: 60},
+			{Name: "B", Pos: 2, Played: 1, Lost: 1, Score: 1, Scored: 60, Received: 70},
+			{Name: "C", Pos: 3},
+		},
+	}
+
+	tb := Compute(s)
+
+	var got []string
+	for _, st := range tb {
+		got = append(got, st.Team)
+	}
+	if want := []string{"A", "B", "C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("teams = %v, want %v", got, want)
+	}
+
+	if is := Diff(tb, s.Positions); len(is) != 0 {
+		t.Errorf("Diff() = %v, want none", is)
+	}
+}
+
+func TestIsBye(t *testing.T) {
+	for name, want := range map[string]bool{
+		"LIBRE":          true,
+		" libre ":        true,
+		"Libre":          true,
+		"LIBRERIA FC":    false,
+		"DEFENSORES U17": false,
+		"":               false,
+	} {
+		if got := cabb.IsBye(name); got != want {
+			t.Errorf("IsBye(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
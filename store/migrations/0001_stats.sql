@@ -0,0 +1,32 @@
+-- Tables created by cmd/stats before the store existed.
+CREATE TABLE IF NOT EXISTS teams (
+       id TEXT PRIMARY KEY,
+       club TEXT NOT NULL,
+       name TEXT NOT NULL,
+       notificationId TEXT
+);
+
+CREATE TABLE IF NOT EXISTS seasons (
+       id INTEGER PRIMARY KEY AUTOINCREMENT,
+       teamId TEXT REFERENCES teams (id) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS season_team_id ON seasons (teamId);
+
+CREATE TABLE IF NOT EXISTS gamedays (
+       id INTEGER PRIMARY KEY AUTOINCREMENT,
+       seasonId INT REFERENCES seasons (id) NOT NULL,
+       name TEXT NOT NULL,
+       date TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS match_results (
+       id INTEGER PRIMARY KEY AUTOINCREMENT,
+       matchId TEXT UNIQUE NOT NULL,
+       homeTeam TEXT NOT NULL,
+       awayTeam TEXT NOT NULL,
+       homeScore INTEGER NOT NULL,
+       awayScore INTEGER NOT NULL,
+       date TEXT NOT NULL,
+       status TEXT NOT NULL
+);
@@ -0,0 +1,67 @@
+-- Box scores and play-by-play.
+CREATE UNIQUE INDEX IF NOT EXISTS gameday_season_name ON gamedays (seasonId, name);
+
+CREATE TABLE IF NOT EXISTS match_details (
+       match_id TEXT PRIMARY KEY,
+       home TEXT NOT NULL,
+       home_id INTEGER NOT NULL,
+       home_score INTEGER NOT NULL,
+       away TEXT NOT NULL,
+       away_id INTEGER NOT NULL,
+       away_score INTEGER NOT NULL,
+       num_periods INTEGER NOT NULL,
+       overtime BOOLEAN NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS match_periods (
+       match_id TEXT NOT NULL,
+       period INTEGER NOT NULL,
+       home_score INTEGER NOT NULL,
+       away_score INTEGER NOT NULL,
+       PRIMARY KEY (match_id, period)
+);
+
+CREATE TABLE IF NOT EXISTS player_stats (
+       match_id TEXT NOT NULL,
+       home BOOLEAN NOT NULL,
+       team TEXT NOT NULL,
+       num TEXT NOT NULL,
+       name TEXT NOT NULL,
+       val INTEGER NOT NULL,
+       points INTEGER NOT NULL,
+       shot1p INTEGER NOT NULL,
+       made1p INTEGER NOT NULL,
+       missed1p INTEGER NOT NULL,
+       shot2p INTEGER NOT NULL,
+       made2p INTEGER NOT NULL,
+       missed2p INTEGER NOT NULL,
+       shot3p INTEGER NOT NULL,
+       made3p INTEGER NOT NULL,
+       missed3p INTEGER NOT NULL,
+       assists INTEGER NOT NULL,
+       turnovers INTEGER NOT NULL,
+       steals INTEGER NOT NULL,
+       fouls INTEGER NOT NULL,
+       fouled INTEGER NOT NULL,
+       rebounds INTEGER NOT NULL,
+       rebounds_off INTEGER NOT NULL,
+       rebounds_def INTEGER NOT NULL,
+       blocks INTEGER NOT NULL,
+       blocked INTEGER NOT NULL,
+       played_ms INTEGER NOT NULL,
+       played TEXT NOT NULL,
+       PRIMARY KEY (match_id, home, num, name)
+);
+
+CREATE TABLE IF NOT EXISTS actions (
+       match_id TEXT NOT NULL,
+       action_num INTEGER NOT NULL,
+       type TEXT NOT NULL,
+       info TEXT NOT NULL,
+       period INTEGER NOT NULL,
+       match_time TEXT NOT NULL,
+       team_id INTEGER NOT NULL,
+       player_num TEXT NOT NULL,
+       actor_id TEXT NOT NULL,
+       PRIMARY KEY (match_id, action_num)
+);
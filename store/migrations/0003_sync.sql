@@ -0,0 +1,10 @@
+-- Fetch state of each match, used to sync only what changed.
+CREATE TABLE match_sync (
+       match_id TEXT PRIMARY KEY,
+       status TEXT NOT NULL,
+       home_score TEXT NOT NULL,
+       away_score TEXT NOT NULL,
+       final BOOLEAN NOT NULL,
+       fetched_at TEXT NOT NULL,
+       error TEXT NOT NULL DEFAULT ''
+);
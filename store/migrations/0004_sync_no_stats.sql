@@ -0,0 +1,4 @@
+-- Matches without stats are a final answer of the API, not a failed fetch to
+-- retry. Errors recorded before can't be told apart from transient failures,
+-- so they're kept and retried by the next sync.
+ALTER TABLE match_sync ADD COLUMN no_stats BOOLEAN NOT NULL DEFAULT FALSE;
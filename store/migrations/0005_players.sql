@@ -0,0 +1,33 @@
+-- Player registry: stable IDs for the players of each team.
+CREATE TABLE players (
+       id INTEGER PRIMARY KEY AUTOINCREMENT,
+       team TEXT NOT NULL,
+       name TEXT NOT NULL
+);
+
+-- Box score (num, name) of a team linked to a player. Names are normalized
+-- and manual links are overrides that indexing never changes.
+CREATE TABLE player_keys (
+       team TEXT NOT NULL,
+       num TEXT NOT NULL,
+       name TEXT NOT NULL,
+       player_id INTEGER NOT NULL REFERENCES players (id),
+       manual BOOLEAN NOT NULL DEFAULT 0,
+       PRIMARY KEY (team, num, name)
+);
+
+CREATE INDEX player_keys_player ON player_keys (player_id);
+
+-- Play-by-play componente_id linked to a player.
+CREATE TABLE player_actors (
+       actor_id TEXT PRIMARY KEY,
+       player_id INTEGER NOT NULL REFERENCES players (id),
+       manual BOOLEAN NOT NULL DEFAULT 0
+);
+
+-- Players known to be different people, with a < b.
+CREATE TABLE player_distinct (
+       a INTEGER NOT NULL REFERENCES players (id),
+       b INTEGER NOT NULL REFERENCES players (id),
+       PRIMARY KEY (a, b)
+);
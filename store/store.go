@@ -0,0 +1,316 @@
+// Package store persists seasons, box scores and play-by-play in a sqlite
+// database, so analyses can run from local data instead of the API.
+package store
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/migrate"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns the schema migrations of the store.
+func Migrations() ([]migrate.Migration, error) {
+	return migrate.Load(migrations, "migrations")
+}
+
+// Migrate applies the pending migrations of the store to db.
+func Migrate(db *sql.DB) ([]migrate.Migration, error) {
+	ms, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.Up(db, ms)
+}
+
+// ErrNotFound is returned when the requested data is not in the store.
+var ErrNotFound = errors.New("not found in store")
+
+// Store is a sqlite database of CABB data.
+type Store struct {
+	db *sqlx.DB
+}
+
+// Open opens or creates the store at path, applying any pending migration.
+func Open(path string) (*Store, error) {
+	db, err := sqlx.Connect("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer, so share one connection between
+	// goroutines instead of failing with database is locked.
+	db.SetMaxOpenConns(1)
+
+	if _, err := Migrate(db.DB); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+// DB returns the underlying database, for queries not covered by the store.
+func (s *Store) DB() *sqlx.DB { return s.db }
+
+// tx runs f in a transaction, committing it if f succeeds.
+func (s *Store) tx(f func(*sqlx.Tx) error) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveTeam inserts or updates a team.
+func (s *Store) SaveTeam(t cabb.Team) error {
+	_, err := s.db.Exec(`INSERT INTO teams (id, club, name, notificationId) VALUES (?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET club = excluded.club, name = excluded.name, notificationId = excluded.notificationId`,
+		t.ID, t.Club, t.Name, t.NotificationID)
+	return err
+}
+
+// SaveSeason stores the game days and matches of the season of a team,
+// updating the results of matches already stored.
+func (s *Store) SaveSeason(teamID string, season cabb.Season) error {
+	return s.tx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("INSERT INTO seasons (teamId) VALUES (?) ON CONFLICT (teamId) DO NOTHING", teamID)
+		if err != nil {
+			return err
+		}
+
+		var seasonID int64
+		if err := tx.Get(&seasonID, "SELECT id FROM seasons WHERE teamId = ?", teamID); err != nil {
+			return err
+		}
+
+		for _, gd := range season.Season {
+			_, err := tx.Exec(`INSERT INTO gamedays (seasonId, name, date) VALUES (?, ?, ?)
+ON CONFLICT (seasonId, name) DO UPDATE SET date = excluded.date`, seasonID, gd.Name, gd.Date)
+			if err != nil {
+				return fmt.Errorf("saving game day %s: %w", gd.Name, err)
+			}
+
+			for _, m := range gd.Matches {
+				if err := saveMatch(tx, m); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func saveMatch(tx *sqlx.Tx, m cabb.Match) error {
+	if m.MatchID == "" {
+		return nil
+	}
+
+	_, err := tx.NamedExec(`INSERT INTO match_results (matchId, homeTeam, awayTeam, homeScore, awayScore, date, status)
+VALUES (:match_id, :home_team, :away_team, :home_score, :away_score, :date, :status)
+ON CONFLICT (matchId) DO UPDATE SET homeScore = excluded.homeScore, awayScore = excluded.awayScore,
+       date = excluded.date, status = excluded.status`, m)
+	if err != nil {
+		return fmt.Errorf("saving match %s: %w", m.MatchID, err)
+	}
+
+	return nil
+}
+
+func saveDetails(tx *sqlx.Tx, matchID string, m cabb.LiveMatch) error {
+	_, err := tx.Exec(`INSERT INTO match_details (match_id, home, home_id, home_score, away, away_id, away_score, num_periods, overtime)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (match_id) DO UPDATE SET home = excluded.home, home_id = excluded.home_id, home_score = excluded.home_score,
+       away = excluded.away, away_id = excluded.away_id, away_score = excluded.away_score,
+       num_periods = excluded.num_periods, overtime = excluded.overtime`,
+		matchID, m.Home, m.HomeID, m.HomeScore, m.Away, m.AwayID, m.AwayScore, m.NumPeriods, m.Overtime)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range m.Periods {
+		_, err := tx.Exec(`INSERT INTO match_periods (match_id, period, home_score, away_score) VALUES (?, ?, ?, ?)
+ON CONFLICT (match_id, period) DO UPDATE SET home_score = excluded.home_score, away_score = excluded.away_score`,
+			matchID, p.Period, p.HomeScore, p.AwayScore)
+		if err != nil {
+			return fmt.Errorf("saving period %d: %w", p.Period, err)
+		}
+	}
+
+	return nil
+}
+
+// playerStats is a row of the player_stats table.
+type playerStats struct {
+	MatchID string `db:"match_id"`
+	Home    bool   `db:"home"`
+	Team    string `db:"team"`
+	cabb.PlayerStats
+}
+
+// SaveStats stores the box score of a match, replacing any previous one.
+func (s *Store) SaveStats(st cabb.Stats) error {
+	if st.MatchID == "" {
+		return errors.New("saving stats: missing match ID")
+	}
+
+	return s.tx(func(tx *sqlx.Tx) error {
+		if err := saveDetails(tx, st.MatchID, st.Match); err != nil {
+			return fmt.Errorf("saving match %s: %w", st.MatchID, err)
+		}
+
+		if _, err := tx.Exec("DELETE FROM player_stats WHERE match_id = ?", st.MatchID); err != nil {
+			return err
+		}
+
+		sides := []struct {
+			home bool
+			team string
+			ps   []cabb.PlayerStats
+		}{
+			{true, st.Match.Home, st.Stats.Home},
+			{false, st.Match.Away, st.Stats.Away},
+		}
+
+		for _, side := range sides {
+			for _, p := range side.ps {
+				_, err := tx.NamedExec(`INSERT INTO player_stats (match_id, home, team, num, name, val, points,
+       shot1p, made1p, missed1p, shot2p, made2p, missed2p, shot3p, made3p, missed3p,
+       assists, turnovers, steals, fouls, fouled, rebounds, rebounds_off, rebounds_def,
+       blocks, blocked, played_ms, played)
+VALUES (:match_id, :home, :team, :num, :name, :val, :points,
+       :shot1p, :made1p, :missed1p, :shot2p, :made2p, :missed2p, :shot3p, :made3p, :missed3p,
+       :assists, :turnovers, :steals, :fouls, :fouled, :rebounds, :rebounds_off, :rebounds_def,
+       :blocks, :blocked, :played_ms, :played)
+ON CONFLICT (match_id, home, num, name) DO NOTHING`,
+					playerStats{MatchID: st.MatchID, Home: side.home, Team: side.team, PlayerStats: p})
+				if err != nil {
+					return fmt.Errorf("saving stats of %s in %s: %w", p.Name, st.MatchID, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// SaveLive stores the play-by-play of a match. Actions are keyed by their
+// ActionNum, so saving the same match again only adds the new actions.
+func (s *Store) SaveLive(l cabb.Live) error {
+	id := l.Match.MatchID
+	if id == "" {
+		return errors.New("saving play-by-play: missing match ID")
+	}
+
+	return s.tx(func(tx *sqlx.Tx) error {
+		if err := saveDetails(tx, id, l.LiveMatch); err != nil {
+			return fmt.Errorf("saving match %s: %w", id, err)
+		}
+
+		for _, a := range l.Live.Actions {
+			_, err := tx.Exec(`INSERT INTO actions (match_id, action_num, type, info, period, match_time, team_id, player_num, actor_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (match_id, action_num) DO UPDATE SET type = excluded.type, info = excluded.info, period = excluded.period,
+       match_time = excluded.match_time, team_id = excluded.team_id, player_num = excluded.player_num, actor_id = excluded.actor_id`,
+				id, a.ActionNum, a.Type, a.Info, a.Period, a.MatchTime, a.TeamID, a.PlayerNum, a.ActorID)
+			if err != nil {
+				return fmt.Errorf("saving action %d of %s: %w", a.ActionNum, id, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func loadDetails(db *sqlx.DB, matchID string) (cabb.LiveMatch, error) {
+	var m cabb.LiveMatch
+
+	err := db.QueryRowx(`SELECT home, home_id, home_score, away, away_id, away_score, num_periods, overtime
+FROM match_details WHERE match_id = ?`, matchID).
+		Scan(&m.Home, &m.HomeID, &m.HomeScore, &m.Away, &m.AwayID, &m.AwayScore, &m.NumPeriods, &m.Overtime)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, fmt.Errorf("match %s: %w", matchID, ErrNotFound)
+	}
+	if err != nil {
+		return m, err
+	}
+
+	err = db.Select(&m.Periods, `SELECT period, home_score AS homescore, away_score AS awayscore
+FROM match_periods WHERE match_id = ? ORDER BY period`, matchID)
+
+	return m, err
+}
+
+// Stats returns the box score of a match.
+func (s *Store) Stats(matchID string) (cabb.Stats, error) {
+	st := cabb.Stats{MatchID: matchID}
+
+	m, err := loadDetails(s.db, matchID)
+	if err != nil {
+		return st, err
+	}
+	st.Match = m
+
+	var rows []playerStats
+	if err := s.db.Select(&rows, "SELECT * FROM player_stats WHERE match_id = ? ORDER BY rowid", matchID); err != nil {
+		return st, err
+	}
+	if len(rows) == 0 {
+		return st, fmt.Errorf("stats of match %s: %w", matchID, ErrNotFound)
+	}
+
+	for _, r := range rows {
+		if r.Home {
+			st.Stats.Home = append(st.Stats.Home, r.PlayerStats)
+		} else {
+			st.Stats.Away = append(st.Stats.Away, r.PlayerStats)
+		}
+	}
+
+	return st, nil
+}
+
+// Live returns the play-by-play of a match, sorted by ActionNum.
+func (s *Store) Live(matchID string) (cabb.Live, error) {
+	l := cabb.Live{Match: cabb.Match{MatchID: matchID}}
+
+	m, err := loadDetails(s.db, matchID)
+	if err != nil {
+		return l, err
+	}
+	l.LiveMatch = m
+
+	rows, err := s.db.Query(`SELECT action_num, type, info, period, match_time, team_id, player_num, actor_id
+FROM actions WHERE match_id = ? ORDER BY action_num`, matchID)
+	if err != nil {
+		return l, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a cabb.Action
+		if err := rows.Scan(&a.ActionNum, &a.Type, &a.Info, &a.Period, &a.MatchTime, &a.TeamID, &a.PlayerNum, &a.ActorID); err != nil {
+			return l, err
+		}
+		l.Live.Actions = append(l.Live.Actions, a)
+	}
+
+	return l, rows.Err()
+}
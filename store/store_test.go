@@ -0,0 +1,160 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/cabb"
+)
+
+// fixture decodes a response served by cmd/cabb-fake.
+func fixture(t *testing.T, path string, v any) {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "cmd", "cabb-fake", "fixtures", path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func open(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := Open(filepath.Join(t.TempDir(), "cabb.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+// count returns the rows of table for the match.
+func count(t *testing.T, s *Store, table, matchID string) int {
+	t.Helper()
+
+	var n int
+	if err := s.DB().Get(&n, "SELECT COUNT(*) FROM "+table+" WHERE match_id = ?", matchID); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestStats(t *testing.T) {
+	s := open(t)
+
+	var st cabb.Stats
+	fixture(t, "stats/M01.json", &st)
+	st.MatchID = "M01"
+
+	// Saving again replaces the box score.
+	for i := 0; i < 2; i++ {
+		if err := s.SaveStats(st); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.Stats("M01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MatchID != st.MatchID || !reflect.DeepEqual(got.Match, st.Match) || !reflect.DeepEqual(got.Stats, st.Stats) {
+		t.Errorf("Stats() = %+v, want %+v", got, st)
+	}
+
+	if n, want := count(t, s, "player_stats", "M01"), len(st.Stats.Home)+len(st.Stats.Away); n != want {
+		t.Errorf("%d player stats, want %d", n, want)
+	}
+	if n := count(t, s, "match_periods", "M01"); n != len(st.Match.Periods) {
+		t.Errorf("%d periods, want %d", n, len(st.Match.Periods))
+	}
+
+	if _, err := s.Stats("M02"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stats() of a missing match error = %v, want ErrNotFound", err)
+	}
+	if err := s.SaveStats(cabb.Stats{}); err == nil {
+		t.Error("saved stats without a match ID")
+	}
+}
+
+func TestLive(t *testing.T) {
+	s := open(t)
+
+	var l cabb.Live
+	fixture(t, "live/M01.json", &l)
+	l.Match.MatchID = "M01"
+
+	// Saving the play-by-play as it grows only adds the new actions.
+	partial := l
+	partial.Live.Actions = l.Live.Actions[:len(l.Live.Actions)/2]
+	for _, l := range []cabb.Live{partial, l, l} {
+		if err := s.SaveLive(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.Live("M01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Match.MatchID != "M01" || !reflect.DeepEqual(got.LiveMatch, l.LiveMatch) || !reflect.DeepEqual(got.Live.Actions, l.Live.Actions) {
+		t.Errorf("Live() = %+v, want %+v", got, l)
+	}
+
+	if n := count(t, s, "actions", "M01"); n != len(l.Live.Actions) {
+		t.Errorf("%d actions, want %d", n, len(l.Live.Actions))
+	}
+	if n := count(t, s, "match_details", "M01"); n != 1 {
+		t.Errorf("%d match details, want 1", n)
+	}
+
+	if _, err := s.Live("M02"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Live() of a missing match error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSaveSeason(t *testing.T) {
+	s := open(t)
+
+	var season cabb.Season
+	fixture(t, "seasons/DEF17.json", &season)
+
+	if err := s.SaveTeam(cabb.Team{ID: "DEF17", Club: "DEFENSORES", Name: "DEFENSORES U17"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.SaveSeason("DEF17", season); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var matches int
+	for _, gd := range season.Season {
+		matches += len(gd.Matches)
+	}
+
+	tables := []struct {
+		table string
+		want  int
+	}{
+		{"seasons", 1},
+		{"gamedays", len(season.Season)},
+		{"match_results", matches},
+	}
+	for _, tt := range tables {
+		var n int
+		if err := s.DB().Get(&n, "SELECT COUNT(*) FROM "+tt.table); err != nil {
+			t.Fatal(err)
+		}
+		if n != tt.want {
+			t.Errorf("%d rows in %s, want %d", n, tt.table, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/inkel/cabb"
+)
+
+// SyncState is the last time the data of a match was fetched, and the state
+// of the match at the time.
+type SyncState struct {
+	MatchID   string
+	Status    string
+	HomeScore string
+	AwayScore string
+	Final     bool
+	FetchedAt time.Time
+	// NoStats is set if the API had no stats for the match, which isn't
+	// fetched again until it changes.
+	NoStats bool
+	// Error is set if the last fetch failed for any other reason.
+	Error string
+}
+
+// NewSyncState returns the state of m fetched at t.
+func NewSyncState(m cabb.Match, t time.Time, err error) SyncState {
+	s := SyncState{
+		MatchID:   m.MatchID,
+		Status:    m.Status,
+		HomeScore: m.HomeScore,
+		AwayScore: m.AwayScore,
+		Final:     m.State == cabb.StatusFinished || m.State == cabb.StatusForfeit,
+		FetchedAt: t,
+	}
+	switch {
+	case cabb.IsNoStats(err):
+		s.NoStats = true
+	case err != nil:
+		s.Error = err.Error()
+	}
+	return s
+}
+
+// Changed reports whether m is different from when it was fetched.
+func (s SyncState) Changed(m cabb.Match) bool {
+	return s.Status != m.Status || s.HomeScore != m.HomeScore || s.AwayScore != m.AwayScore
+}
+
+// Done reports whether m doesn't need to be fetched again: it was final when
+// fetched, hasn't changed since, and either its stats were stored or the API
+// had none. Failed fetches are always retried.
+func (s SyncState) Done(m cabb.Match) bool {
+	return s.Final && !s.Changed(m) && s.Error == ""
+}
+
+// SyncState returns the state of the last fetch of a match. The bool is false
+// if it was never fetched.
+func (s *Store) SyncState(matchID string) (SyncState, bool, error) {
+	st := SyncState{MatchID: matchID}
+
+	var at string
+
+	err := s.db.QueryRow(`SELECT status, home_score, away_score, final, fetched_at, no_stats, error FROM match_sync WHERE match_id = ?`, matchID).
+		Scan(&st.Status, &st.HomeScore, &st.AwayScore, &st.Final, &at, &st.NoStats, &st.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, false, nil
+	}
+	if err != nil {
+		return st, false, err
+	}
+
+	st.FetchedAt, _ = time.Parse(time.RFC3339, at)
+
+	return st, true, nil
+}
+
+// SaveSyncState records the state of the last fetch of a match.
+func (s *Store) SaveSyncState(st SyncState) error {
+	_, err := s.db.Exec(`INSERT INTO match_sync (match_id, status, home_score, away_score, final, fetched_at, no_stats, error)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (match_id) DO UPDATE SET status = excluded.status, home_score = excluded.home_score, away_score = excluded.away_score,
+       final = excluded.final, fetched_at = excluded.fetched_at, no_stats = excluded.no_stats, error = excluded.error`,
+		st.MatchID, st.Status, st.HomeScore, st.AwayScore, st.Final, st.FetchedAt.UTC().Format(time.RFC3339), st.NoStats, st.Error)
+	return err
+}
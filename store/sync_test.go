@@ -0,0 +1,125 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/inkel/cabb"
+	"github.com/inkel/cabb/migrate"
+)
+
+func TestSyncState(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "cabb.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	m := cabb.Match{MatchID: "M01", HomeScore: "64", AwayScore: "60", Status: "Finalizado"}
+	m.Parse()
+
+	at := time.Date(2024, time.April, 6, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		err     error
+		noStats bool
+		msg     string
+	}{
+		{"fetched", nil, false, ""},
+		{"no stats", &cabb.APIError{Endpoint: "estadisticas", Message: "no hay estadísticas"}, true, ""},
+		{"expired", &cabb.SessionExpiredError{APIError: cabb.APIError{Endpoint: "estadisticas", Message: "key no válida"}},
+			false, "estadisticas: session expired: key no válida"},
+		{"failed", errors.New("connection refused"), false, "connection refused"},
+		{"api error", &cabb.APIError{Endpoint: "estadisticas", Message: "servicio no disponible"},
+			false, "estadisticas: error response: servicio no disponible"},
+	}
+
+	for _, tt := range tests {
+		st := NewSyncState(m, at, tt.err)
+		if st.NoStats != tt.noStats || st.Error != tt.msg || !st.Final {
+			t.Errorf("%s: NewSyncState() = %+v", tt.name, st)
+		}
+
+		if err := s.SaveSyncState(st); err != nil {
+			t.Fatal(err)
+		}
+
+		got, ok, err := s.SyncState(m.MatchID)
+		if err != nil || !ok {
+			t.Fatalf("%s: SyncState() = %v, %v", tt.name, ok, err)
+		}
+		if got != st {
+			t.Errorf("%s: SyncState() = %+v, want %+v", tt.name, got, st)
+		}
+		if got.Changed(m) {
+			t.Errorf("%s: unchanged match reported as changed", tt.name)
+		}
+		if done := tt.msg == ""; got.Done(m) != done {
+			t.Errorf("%s: Done() = %v, want %v", tt.name, got.Done(m), done)
+		}
+	}
+
+	if _, ok, err := s.SyncState("M99"); ok || err != nil {
+		t.Errorf("SyncState() of an unknown match = %v, %v", ok, err)
+	}
+}
+
+func TestSyncStateMigration(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cabb.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ms, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrate.Up(db, ms[:3]); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, msg := range map[string]string{
+		"M01": "estadisticas.ashx: error response: no hay estadísticas",
+		"M02": "estadisticas.ashx: session expired: key no válida",
+	} {
+		_, err := db.Exec(`INSERT INTO match_sync (match_id, status, home_score, away_score, final, fetched_at, error)
+VALUES (?, 'Finalizado', '1', '0', TRUE, '2024-04-06T20:00:00Z', ?)`, id, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := migrate.Up(db, ms); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("SELECT match_id, no_stats, error FROM match_sync ORDER BY match_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	// Errors recorded before are kept, so they're retried.
+	var n int
+	for rows.Next() {
+		var (
+			id, msg string
+			noStats bool
+		)
+		if err := rows.Scan(&id, &noStats, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if noStats || msg == "" {
+			t.Errorf("%s: no_stats = %v, error = %q", id, noStats, msg)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("%d rows after migrating, want 2", n)
+	}
+}
@@ -0,0 +1,189 @@
+package cabb
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+type LiveEventKind int
+
+const (
+	// LiveAction is a new action in the play-by-play.
+	LiveAction LiveEventKind = iota
+	// LiveScore is a change in the score of the match, either the totals or
+	// the score of a period.
+	LiveScore
+	// LivePeriod is the start of a new period.
+	LivePeriod
+	// LiveError is a failure polling the match; watching continues.
+	LiveError
+)
+
+// LiveEvent is an update of a match being watched. Only the fields relevant
+// to Kind are set.
+type LiveEvent struct {
+	Kind      LiveEventKind
+	Action    Action
+	HomeScore int
+	AwayScore int
+	// Periods holds the score of every period on LiveScore events.
+	Periods []Period
+	Period  int
+	Err     error
+}
+
+// DefaultWatchInterval is the time between polls used by Watch.
+const DefaultWatchInterval = 15 * time.Second
+
+type watchConfig struct {
+	interval time.Duration
+	ended    func(Live) bool
+	since    *Live
+}
+
+// WatchOption configures Client.Watch.
+type WatchOption func(*watchConfig)
+
+// WatchInterval sets the time between polls.
+func WatchInterval(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		if d > 0 {
+			c.interval = d
+		}
+	}
+}
+
+// WatchUntil sets the function reporting whether the match has ended, after
+// which no more polls are made. It defaults to Live.Ended.
+func WatchUntil(ended func(Live) bool) WatchOption {
+	return func(c *watchConfig) { c.ended = ended }
+}
+
+// WatchSince skips the actions, score and period already present in l,
+// usually a snapshot obtained with Client.Live.
+func WatchSince(l Live) WatchOption {
+	return func(c *watchConfig) { c.since = &l }
+}
+
+// Watch polls the live play-by-play of m and sends on the returned channel
+// the new actions, score changes and period changes. The channel is closed
+// when the match ends or ctx is done.
+func (c *Client) Watch(ctx context.Context, m Match, opts ...WatchOption) <-chan LiveEvent {
+	cfg := watchConfig{
+		interval: DefaultWatchInterval,
+		ended:    Live.Ended,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ch := make(chan LiveEvent, 16)
+
+	go func() {
+		defer close(ch)
+
+		w := watcher{seen: make(map[int]bool)}
+		if cfg.since != nil {
+			w.update(*cfg.since)
+		}
+
+		send := func(e LiveEvent) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		t := time.NewTicker(cfg.interval)
+		defer t.Stop()
+
+		for {
+			l, err := c.LiveContext(ctx, m)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				if !send(LiveEvent{Kind: LiveError, Err: err}) {
+					return
+				}
+			} else {
+				for _, e := range w.update(l) {
+					if !send(e) {
+						return
+					}
+				}
+
+				if cfg.ended(l) {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// watcher keeps the state of a watched match to emit only what changed.
+type watcher struct {
+	seen       map[int]bool
+	period     int
+	home, away int
+	periods    []Period
+}
+
+func (w *watcher) update(l Live) []LiveEvent {
+	var es []LiveEvent
+
+	var as []Action
+	for _, a := range l.Live.Actions {
+		if !w.seen[a.ActionNum] {
+			w.seen[a.ActionNum] = true
+			as = append(as, a)
+		}
+	}
+	sort.SliceStable(as, func(i, j int) bool { return as[i].ActionNum < as[j].ActionNum })
+
+	for _, a := range as {
+		if a.Period > w.period {
+			w.period = a.Period
+			es = append(es, LiveEvent{Kind: LivePeriod, Period: a.Period})
+		}
+		es = append(es, LiveEvent{Kind: LiveAction, Action: a, Period: a.Period})
+	}
+
+	if h, a := l.LiveMatch.HomeScore, l.LiveMatch.AwayScore; h != w.home || a != w.away || !samePeriods(l.LiveMatch.Periods, w.periods) {
+		w.home, w.away = h, a
+		w.periods = append([]Period(nil), l.LiveMatch.Periods...)
+		es = append(es, LiveEvent{
+			Kind:      LiveScore,
+			HomeScore: h,
+			AwayScore: a,
+			Periods:   append([]Period(nil), w.periods...),
+			Period:    w.period,
+		})
+	}
+
+	return es
+}
+
+func samePeriods(a, b []Period) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package cabb
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcherUpdate(t *testing.T) {
+	action := func(n, period int) Action {
+		return Action{ActionNum: n, Period: period, Type: "CANASTA-2P", TeamID: 1}
+	}
+
+	live := func(home, away int, periods []Period, as ...Action) Live {
+		var l Live
+		l.LiveMatch = LiveMatch{HomeScore: home, AwayScore: away, Periods: periods}
+		l.Live.Actions = as
+		return l
+	}
+
+	p1 := []Period{{Period: 1, HomeScore: 2}}
+	p2 := []Period{{Period: 1, HomeScore: 2}, {Period: 2, AwayScore: 3}}
+
+	tests := []struct {
+		name  string
+		live  Live
+		kinds []LiveEventKind
+	}{
+		{"first action", live(2, 0, p1, action(1, 1)), []LiveEventKind{LivePeriod, LiveAction, LiveScore}},
+		{"nothing new", live(2, 0, p1, action(1, 1)), nil},
+		{"new period", live(2, 3, p2, action(1, 1), action(2, 2)), []LiveEventKind{LivePeriod, LiveAction, LiveScore}},
+		// A correction of a period score without a change in the totals.
+		{"period corrected", live(2, 3, []Period{{Period: 1}, {Period: 2, HomeScore: 2, AwayScore: 3}}, action(1, 1), action(2, 2)), []LiveEventKind{LiveScore}},
+	}
+
+	w := watcher{seen: make(map[int]bool)}
+
+	for _, tt := range tests {
+		es := w.update(tt.live)
+
+		if len(es) != len(tt.kinds) {
+			t.Fatalf("%s: %d events, want %d: %+v", tt.name, len(es), len(tt.kinds), es)
+		}
+
+		for i, e := range es {
+			if e.Kind != tt.kinds[i] {
+				t.Errorf("%s: event %d is %v, want %v", tt.name, i, e.Kind, tt.kinds[i])
+			}
+
+			if e.Kind == LiveScore && !samePeriods(e.Periods, tt.live.LiveMatch.Periods) {
+				t.Errorf("%s: periods = %+v, want %+v", tt.name, e.Periods, tt.live.LiveMatch.Periods)
+			}
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	actions := []Action{
+		{ActionNum: 1, Period: 1, Type: "INICIO-PARTIDO"},
+		{ActionNum: 2, Period: 1, Type: "CANASTA-2P", TeamID: 1},
+		{ActionNum: 3, Period: 2, Type: "CANASTA-3P", TeamID: 2},
+		{ActionNum: 4, Period: 2, Type: "FIN-PARTIDO"},
+	}
+
+	// Every poll reveals one more action, in reverse order, as the feed
+	// doesn't guarantee any.
+	var (
+		mu    sync.Mutex
+		polls int
+	)
+	api := newFakeAPI(t, func(*http.Request) any {
+		mu.Lock()
+		defer mu.Unlock()
+		if polls < len(actions) {
+			polls++
+		}
+
+		var l Live
+		l.Result = "correcto"
+		for i := polls - 1; i >= 0; i-- {
+			l.Live.Actions = append(l.Live.Actions, actions[i])
+		}
+		return l
+	})
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL), WithRateLimit(0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var got []int
+	for e := range c.Watch(ctx, Match{MatchID: "M01"}, WatchInterval(time.Millisecond)) {
+		if e.Kind == LiveAction {
+			got = append(got, e.Action.ActionNum)
+		}
+	}
+
+	if ctx.Err() != nil {
+		t.Fatal("Watch() didn't stop when the match ended")
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %v, want %v", got, want)
+	}
+}
+
+func TestWatchCanceled(t *testing.T) {
+	api := newFakeAPI(t, func(*http.Request) any {
+		return map[string]string{"resultado": "correcto"}
+	})
+
+	c, err := NewClient("u1", "d1", WithBaseURL(api.URL), WithRateLimit(0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := c.Watch(ctx, Match{MatchID: "M01"}, WatchInterval(time.Millisecond))
+	cancel()
+
+	select {
+	case <-drain(ch):
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() didn't close the channel after canceling the context")
+	}
+}
+
+func drain(ch <-chan LiveEvent) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}